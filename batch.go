@@ -0,0 +1,303 @@
+package leaderboard
+
+import (
+	"errors"
+	"math"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Batch queues reads and writes on leaderboards and runs them in a single
+// pipeline. Each queued operation returns a future resolved by Exec. The
+// leaderboards of a batch must share a backend.
+type Batch struct {
+	board *Leaderboard
+	ops   []batchOp
+	done  bool
+}
+
+// batchOp sends the commands of one operation and reads back their replies
+// in the same order.
+type batchOp struct {
+	send    func(conn redis.Conn) error
+	receive func(conn redis.Conn)
+}
+
+type MemberFuture struct {
+	user User
+	err  error
+}
+
+type IntFuture struct {
+	value int
+	err   error
+}
+
+type UsersFuture struct {
+	users []User
+	err   error
+}
+
+/* End Structs model */
+
+// ErrBatchPending is returned by the futures of a batch not executed yet.
+var ErrBatchPending = errors.New("leaderboard: batch not executed")
+
+// leadersScript returns a page of members as member, score, rank and ghost
+// flag quadruples, clamping the page and ranking ghosts like GetLeaders.
+// KEYS: board, ghosts.
+// ARGV: page, page size, sort order, tie policy, ghost policy.
+var leadersScript = redis.NewScript(2, ghostsLua+`
+local total = redis.call('ZCARD', KEYS[1])
+local size = tonumber(ARGV[2])
+local page = tonumber(ARGV[1])
+local pages = math.ceil(total / size)
+if page > pages then page = pages end
+if page < 1 then page = 1 end
+local start = (page - 1) * size
+local command = 'ZREVRANGE'
+if ARGV[3] == '1' then command = 'ZRANGE' end
+local values = redis.call(command, KEYS[1], start, start + size - 1, 'WITHSCORES')
+local result = {}
+for i = 1, #values, 2 do
+	local rank = start + (i + 1) / 2
+	if ARGV[4] == '1' then
+		if ARGV[3] == '1' then
+			rank = redis.call('ZCOUNT', KEYS[1], '-inf', '(' .. values[i + 1]) + 1
+		else
+			rank = redis.call('ZCOUNT', KEYS[1], '(' .. values[i + 1], '+inf') + 1
+		end
+	end
+	local ghost = 0
+	if ARGV[5] ~= '0' then
+		ghost = redis.call('ZSCORE', KEYS[2], values[i]) and 1 or 0
+	end
+	if ARGV[5] == '2' then
+		if ghost == 1 then
+			rank = 0
+		else
+			rank = rank - ghostsBefore(rank - 1, tonumber(values[i + 1]), ARGV[3], ARGV[4])
+		end
+	end
+	table.insert(result, values[i])
+	table.insert(result, values[i + 1])
+	table.insert(result, rank)
+	table.insert(result, ghost)
+end
+return result
+`)
+
+/* Private functions */
+
+func (b *Batch) queue(l *Leaderboard, op batchOp) {
+	if b.board == nil {
+		b.board = l
+	}
+	b.ops = append(b.ops, op)
+}
+
+func (b *Batch) queueMember(l *Leaderboard, member string, send func(conn redis.Conn) error, receive func(conn redis.Conn, f *MemberFuture)) *MemberFuture {
+	f := &MemberFuture{user: User{Name: member}, err: ErrBatchPending}
+	b.queue(l, batchOp{
+		send:    send,
+		receive: func(conn redis.Conn) { receive(conn, f) },
+	})
+	return f
+}
+
+func (b *Batch) queueInt(l *Leaderboard, send func(conn redis.Conn) error, receive func(conn redis.Conn) (int, error)) *IntFuture {
+	f := &IntFuture{err: ErrBatchPending}
+	b.queue(l, batchOp{
+		send: send,
+		receive: func(conn redis.Conn) {
+			f.value, f.err = receive(conn)
+		},
+	})
+	return f
+}
+
+func (b *Batch) rankMember(l *Leaderboard, member string, score int) *MemberFuture {
+	extra := 0
+	return b.queueMember(l, member, func(conn redis.Conn) error {
+		extra = l.sendScore(conn, member, score) - 1
+		return l.sendMember(conn, member)
+	}, func(conn redis.Conn, f *MemberFuture) {
+		_, err := conn.Receive()
+		for i := 0; i < extra; i++ {
+			conn.Receive()
+		}
+		if err != nil {
+			conn.Receive()
+			f.err = err
+			return
+		}
+		f.user, f.err = receiveMember(conn, member)
+	})
+}
+
+func (b *Batch) removeMember(l *Leaderboard, member string) *MemberFuture {
+	extra := 0
+	return b.queueMember(l, member, func(conn redis.Conn) error {
+		l.sendMember(conn, member)
+		extra = l.sendRemoval(conn, member) - 1
+		return nil
+	}, func(conn redis.Conn, f *MemberFuture) {
+		f.user, _ = receiveMember(conn, member)
+		_, f.err = conn.Receive()
+		for i := 0; i < extra; i++ {
+			conn.Receive()
+		}
+	})
+}
+
+// interceptMember runs the queueing of a member operation through the
+// interceptors of l. An interceptor short-circuiting the operation resolves
+// its future with the result or the error returned.
+func (b *Batch) interceptMember(l *Leaderboard, operation string, args []interface{}, queue func(args []interface{}) *MemberFuture) *MemberFuture {
+	result, err := l.invoke(operation, args, func(args []interface{}) (interface{}, error) {
+		return queue(args), nil
+	})
+	if f, ok := result.(*MemberFuture); ok && err == nil {
+		return f
+	}
+	user, ok := result.(User)
+	if !ok {
+		user = User{Name: args[0].(string)}
+	}
+	return &MemberFuture{user: user, err: err}
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// RankMember queues RankMember.
+func (b *Batch) RankMember(l *Leaderboard, member string, score int) *MemberFuture {
+	return b.interceptMember(l, "Batch.RankMember", []interface{}{member, score}, func(args []interface{}) *MemberFuture {
+		return b.rankMember(l, args[0].(string), args[1].(int))
+	})
+}
+
+// GetMember queues GetMember.
+func (b *Batch) GetMember(l *Leaderboard, member string) *MemberFuture {
+	return b.queueMember(l, member, func(conn redis.Conn) error {
+		return l.sendMember(conn, member)
+	}, func(conn redis.Conn, f *MemberFuture) {
+		f.user, f.err = receiveMember(conn, member)
+	})
+}
+
+// RemoveMember queues RemoveMember.
+func (b *Batch) RemoveMember(l *Leaderboard, member string) *MemberFuture {
+	return b.interceptMember(l, "Batch.RemoveMember", []interface{}{member}, func(args []interface{}) *MemberFuture {
+		return b.removeMember(l, args[0].(string))
+	})
+}
+
+// GetRank queues GetRank. Members not ranked resolve to 0.
+func (b *Batch) GetRank(l *Leaderboard, member string) *IntFuture {
+	return b.queueInt(l, func(conn redis.Conn) error {
+		return l.sendMember(conn, member)
+	}, func(conn redis.Conn) (int, error) {
+		user, err := receiveMember(conn, member)
+		if err == redis.ErrNil {
+			err = nil
+		}
+		return user.Rank, err
+	})
+}
+
+// TotalMembers queues TotalMembers.
+func (b *Batch) TotalMembers(l *Leaderboard) *IntFuture {
+	return b.queueInt(l, func(conn redis.Conn) error {
+		return conn.Send("ZCARD", l.key())
+	}, func(conn redis.Conn) (int, error) {
+		return redis.Int(conn.Receive())
+	})
+}
+
+// TotalPages queues TotalPages.
+func (b *Batch) TotalPages(l *Leaderboard) *IntFuture {
+	return b.queueInt(l, func(conn redis.Conn) error {
+		return conn.Send("ZCARD", l.key())
+	}, func(conn redis.Conn) (int, error) {
+		total, err := redis.Int(conn.Receive())
+		return int(math.Ceil(float64(total) / float64(l.PageSize))), err
+	})
+}
+
+// GetLeaders queues GetLeaders. The page is always read from the leaderboard,
+// never from a top snapshot.
+func (b *Batch) GetLeaders(l *Leaderboard, page int) *UsersFuture {
+	f := &UsersFuture{err: ErrBatchPending}
+	b.queue(l, batchOp{
+		send: func(conn redis.Conn) error {
+			return leadersScript.Send(conn, l.key(), l.ghostsKey(), page, l.PageSize, int(l.Order), int(l.ties), int(l.ghosts))
+		},
+		receive: func(conn redis.Conn) {
+			values, err := redis.Values(conn.Receive())
+			if err != nil {
+				f.err = err
+				return
+			}
+			users := make([]User, l.PageSize)
+			for i := 0; len(values) > 0 && i < l.PageSize; i++ {
+				ghost := 0
+				if values, err = redis.Scan(values, &users[i].Name, &users[i].Score, &users[i].Rank, &ghost); err != nil {
+					f.err = err
+					return
+				}
+				users[i].Ghost = ghost == 1
+			}
+			f.users, f.err = users, nil
+		},
+	})
+	return f
+}
+
+// Exec runs the queued operations in one pipeline and resolves their
+// futures. A batch runs once; it returns an error only when the pipeline
+// itself failed, errors of single operations are held by their futures.
+func (b *Batch) Exec() error {
+	if b.done {
+		return errors.New("leaderboard: batch already executed")
+	}
+	b.done = true
+	if len(b.ops) == 0 {
+		return nil
+	}
+	conn := b.board.conn()
+	defer conn.Close()
+	for _, op := range b.ops {
+		if err := op.send(conn); err != nil {
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	for _, op := range b.ops {
+		op.receive(conn)
+	}
+	return conn.Err()
+}
+
+func (f *MemberFuture) Result() (User, error) {
+	return f.user, f.err
+}
+
+func (f *IntFuture) Result() (int, error) {
+	return f.value, f.err
+}
+
+func (f *UsersFuture) Result() ([]User, error) {
+	return f.users, f.err
+}
+
+/* End Public functions */
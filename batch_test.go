@@ -0,0 +1,94 @@
+package leaderboard
+
+import (
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestBatch(c *gocheck.C) {
+	metrics := &countingMetrics{commands: map[string]int{}}
+	board, _ := New(redisSettings, "batch", WithPageSize(5), WithMetrics(metrics))
+	other, _ := New(redisSettings, "batchOther", WithSortOrder(LowToHigh), WithTiePolicy(TieShared))
+	for i := 0; i < 7; i++ {
+		board.RankMember("member_"+strconv.Itoa(i), 10*i)
+	}
+
+	batch := NewBatch()
+	ranked := batch.RankMember(&board, "dayvson", 45)
+	member := batch.GetMember(&board, "member_6")
+	missing := batch.GetMember(&board, "unknown")
+	rank := batch.GetRank(&board, "member_0")
+	total := batch.TotalMembers(&board)
+	pages := batch.TotalPages(&board)
+	leaders := batch.GetLeaders(&board, 9)
+	removed := batch.RemoveMember(&board, "member_1")
+	otherRanked := batch.RankMember(&other, "felipe", 3)
+	otherLeaders := batch.GetLeaders(&other, 1)
+
+	_, err := member.Result()
+	c.Assert(err, gocheck.Equals, ErrBatchPending)
+	metrics.commands = map[string]int{}
+	c.Assert(batch.Exec(), gocheck.IsNil)
+	c.Assert(metrics.commands["ZADD"], gocheck.Equals, 4)
+	c.Assert(metrics.commands["EVAL"], gocheck.Equals, 8)
+
+	user, err := ranked.Result()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Rank, gocheck.Equals, 3)
+	user, _ = member.Result()
+	c.Assert(user.Score, gocheck.Equals, 60)
+	c.Assert(user.Rank, gocheck.Equals, 1)
+	_, err = missing.Result()
+	c.Assert(err, gocheck.Equals, redis.ErrNil)
+	value, _ := rank.Result()
+	c.Assert(value, gocheck.Equals, 8)
+	value, _ = total.Result()
+	c.Assert(value, gocheck.Equals, 8)
+	value, _ = pages.Result()
+	c.Assert(value, gocheck.Equals, 2)
+	users, err := leaders.Result()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(users), gocheck.Equals, 5)
+	c.Assert(users[0].Name, gocheck.Equals, "member_2")
+	c.Assert(users[0].Rank, gocheck.Equals, 6)
+	c.Assert(users[3].Rank, gocheck.Equals, 0)
+	user, err = removed.Result()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Rank, gocheck.Equals, 7)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 7)
+	user, _ = otherRanked.Result()
+	c.Assert(user.Rank, gocheck.Equals, 1)
+	users, _ = otherLeaders.Result()
+	c.Assert(users[0].Name, gocheck.Equals, "felipe")
+
+	c.Assert(batch.Exec(), gocheck.NotNil)
+}
+
+func (s *S) TestBatchGetLeadersWithGhosts(c *gocheck.C) {
+	for _, policy := range []GhostPolicy{GhostsRanked, GhostsUnranked} {
+		for _, ties := range []TiePolicy{TieByMember, TieShared} {
+			board, _ := New(redisSettings, "batchGhosts", WithPageSize(3), WithGhostPolicy(policy), WithTiePolicy(ties))
+			board.AddGhost("developer", 100)
+			board.AddGhost("tester", 80)
+			board.RankMember("dayvson", 120)
+			board.RankMember("felipe", 100)
+			board.RankMember("arthur", 90)
+			for page := 1; page <= 2; page++ {
+				batch := NewBatch()
+				leaders := batch.GetLeaders(&board, page)
+				c.Assert(batch.Exec(), gocheck.IsNil)
+				users, err := leaders.Result()
+				c.Assert(err, gocheck.IsNil)
+				c.Assert(users, gocheck.DeepEquals, board.GetLeaders(page))
+			}
+		}
+	}
+	plain := NewLeaderboard(redisSettings, "batchGhosts", 3)
+	batch := NewBatch()
+	leaders := batch.GetLeaders(&plain, 1)
+	batch.Exec()
+	users, _ := leaders.Result()
+	c.Assert(users[1].Ghost, gocheck.Equals, false)
+}
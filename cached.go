@@ -0,0 +1,212 @@
+package leaderboard
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+/* Structs model */
+
+// CachedReader serves GetMember and GetLeaders from a client-side cache kept
+// exact by Redis. The rueidis client speaks RESP3 and enables CLIENT TRACKING,
+// so Redis invalidates a cached reply the moment the leaderboard changes; TTL
+// only bounds how long an untouched reply stays in memory.
+type CachedReader struct {
+	Leaderboard Leaderboard
+	Client      rueidis.Client
+	TTL         time.Duration
+}
+
+// cachedRanks is the rankSource reading the client-side cache of a reader.
+type cachedRanks struct {
+	r   *CachedReader
+	ctx context.Context
+}
+
+/* End Structs model */
+
+/* Private functions */
+
+// cachedRange returns the cacheable read of the members between two offsets,
+// in the order of the leaderboard.
+func (r *CachedReader) cachedRange(start int, stop int) rueidis.Cacheable {
+	key := r.Leaderboard.key()
+	rangeCmd := r.Client.B().Zrange().Key(key).Min(strconv.Itoa(start)).Max(strconv.Itoa(stop))
+	if r.Leaderboard.Order == LowToHigh {
+		return rangeCmd.Withscores().Cache()
+	}
+	return rangeCmd.Rev().Withscores().Cache()
+}
+
+// cachedBetter returns the cacheable count of the members of the sorted set
+// key placed strictly before score.
+func (r *CachedReader) cachedBetter(key string, score int) rueidis.Cacheable {
+	min, max := r.Leaderboard.scoreRange(score)
+	return r.Client.B().Zcount().Key(key).Min(min).Max(max).Cache()
+}
+
+// cachedRank returns the cacheable position of member, counted from zero in
+// the order of the leaderboard.
+func (r *CachedReader) cachedRank(member string) rueidis.Cacheable {
+	key := r.Leaderboard.key()
+	if r.Leaderboard.Order == LowToHigh {
+		return r.Client.B().Zrank().Key(key).Member(member).Cache()
+	}
+	return r.Client.B().Zrevrank().Key(key).Member(member).Cache()
+}
+
+// do runs commands through the cache, failing on the first error other than
+// a nil reply.
+func (s cachedRanks) do(commands []rueidis.CacheableTTL) ([]rueidis.RedisResult, error) {
+	if len(commands) == 0 {
+		return nil, nil
+	}
+	results := s.r.Client.DoMultiCache(s.ctx, commands...)
+	for _, result := range results {
+		if err := result.Error(); err != nil && !rueidis.IsRedisNil(err) {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (s cachedRanks) countBefore(key string, scores []int) ([]int, error) {
+	commands := make([]rueidis.CacheableTTL, len(scores))
+	for i, score := range scores {
+		commands[i] = rueidis.CT(s.r.cachedBetter(key, score), s.r.TTL)
+	}
+	results, err := s.do(commands)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int, len(results))
+	for i, result := range results {
+		count, err := result.AsInt64()
+		if err != nil {
+			return nil, err
+		}
+		counts[i] = int(count)
+	}
+	return counts, nil
+}
+
+func (s cachedRanks) holds(key string, members []string) ([]bool, error) {
+	commands := make([]rueidis.CacheableTTL, len(members))
+	for i, member := range members {
+		commands[i] = rueidis.CT(s.r.Client.B().Zscore().Key(key).Member(member).Cache(), s.r.TTL)
+	}
+	results, err := s.do(commands)
+	if err != nil {
+		return nil, err
+	}
+	held := make([]bool, len(results))
+	for i, result := range results {
+		held[i] = result.Error() == nil
+	}
+	return held, nil
+}
+
+func (s cachedRanks) holding(key string, scores []int) ([][]string, error) {
+	commands := make([]rueidis.CacheableTTL, len(scores))
+	for i, score := range scores {
+		bound := strconv.Itoa(score)
+		commands[i] = rueidis.CT(s.r.Client.B().Zrangebyscore().Key(key).Min(bound).Max(bound).Cache(), s.r.TTL)
+	}
+	results, err := s.do(commands)
+	if err != nil {
+		return nil, err
+	}
+	members := make([][]string, len(results))
+	for i, result := range results {
+		if members[i], err = result.AsStrSlice(); err != nil {
+			return nil, err
+		}
+	}
+	return members, nil
+}
+
+func (s cachedRanks) positions(members []string) ([]int, error) {
+	commands := make([]rueidis.CacheableTTL, len(members))
+	for i, member := range members {
+		commands[i] = rueidis.CT(s.r.cachedRank(member), s.r.TTL)
+	}
+	results, err := s.do(commands)
+	if err != nil {
+		return nil, err
+	}
+	positions := make([]int, len(results))
+	for i, result := range results {
+		position, err := result.AsInt64()
+		switch {
+		case rueidis.IsRedisNil(err):
+			position = -1
+		case err != nil:
+			return nil, err
+		}
+		positions[i] = int(position)
+	}
+	return positions, nil
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+func NewCachedReader(l Leaderboard, client rueidis.Client, ttl time.Duration) *CachedReader {
+	return &CachedReader{Leaderboard: l, Client: client, TTL: ttl}
+}
+
+// GetMember returns the score and rank of a member. Members not ranked get a
+// zero Rank and an error for which rueidis.IsRedisNil holds.
+func (r *CachedReader) GetMember(ctx context.Context, member string) (User, error) {
+	results := r.Client.DoMultiCache(ctx,
+		rueidis.CT(r.Client.B().Zscore().Key(r.Leaderboard.key()).Member(member).Cache(), r.TTL),
+		rueidis.CT(r.cachedRank(member), r.TTL))
+	score, err := results[0].AsFloat64()
+	if err != nil {
+		return User{Name: member}, err
+	}
+	rank, err := results[1].AsInt64()
+	if err != nil {
+		return User{Name: member}, err
+	}
+	users := []User{{Name: member, Score: int(score), Rank: int(rank) + 1}}
+	if err := r.Leaderboard.rankUsers(cachedRanks{r, ctx}, users); err != nil {
+		return User{Name: member}, err
+	}
+	return users[0], nil
+}
+
+// GetLeaders returns a page of the leaderboard like Leaderboard.GetLeaders.
+func (r *CachedReader) GetLeaders(ctx context.Context, page int) ([]User, error) {
+	pageSize := r.Leaderboard.PageSize
+	total, err := r.Client.DoCache(ctx, r.Client.B().Zcard().Key(r.Leaderboard.key()).Cache(), r.TTL).AsInt64()
+	if err != nil {
+		return nil, err
+	}
+	pages := (int(total) + pageSize - 1) / pageSize
+	if page > pages {
+		page = pages
+	}
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * pageSize
+	scores, err := r.Client.DoCache(ctx, r.cachedRange(start, start+pageSize-1), r.TTL).AsZScores()
+	if err != nil {
+		return nil, err
+	}
+	users := make([]User, pageSize)
+	for i, score := range scores {
+		users[i] = User{Name: score.Member, Score: int(score.Score), Rank: start + i + 1}
+	}
+	if err := r.Leaderboard.rankUsers(cachedRanks{r, ctx}, users[:len(scores)]); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+/* End Public functions */
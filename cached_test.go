@@ -0,0 +1,104 @@
+package leaderboard
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/rueidis"
+	"launchpad.net/gocheck"
+)
+
+// newCachingClient connects a caching client to the test Redis, skipping the
+// test when it does not speak RESP3 with client tracking.
+func newCachingClient(c *gocheck.C) rueidis.Client {
+	client, err := rueidis.NewClient(rueidis.ClientOption{InitAddress: []string{redisSettings.Host}, Password: redisSettings.Password, ForceSingleClient: true, DisableRetry: true})
+	if err != nil {
+		c.Skip("redis without client tracking: " + err.Error())
+	}
+	return client
+}
+
+func (s *S) TestCachedReaderGetMember(c *gocheck.C) {
+	client := newCachingClient(c)
+	defer client.Close()
+	board, _ := New(redisSettings, "cachedReader", WithTiePolicy(TieShared))
+	reader := NewCachedReader(board, client, time.Minute)
+	board.RankMember("dayvson", 300)
+	board.RankMember("arthur", 200)
+	board.RankMember("felipe", 200)
+
+	ctx := context.Background()
+	felipe, err := reader.GetMember(ctx, "felipe")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(felipe.Score, gocheck.Equals, 200)
+	c.Assert(felipe.Rank, gocheck.Equals, 2)
+	c.Assert(client.DoCache(ctx, reader.cachedRank("felipe"), time.Minute).IsCacheHit(), gocheck.Equals, true)
+
+	board.RankMember("felipe", 400)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(client.DoCache(ctx, reader.cachedRank("felipe"), time.Minute).IsCacheHit(), gocheck.Equals, false)
+	felipe, _ = reader.GetMember(ctx, "felipe")
+	c.Assert(felipe.Score, gocheck.Equals, 400)
+	c.Assert(felipe.Rank, gocheck.Equals, 1)
+
+	_, err = reader.GetMember(ctx, "unknown")
+	c.Assert(rueidis.IsRedisNil(err), gocheck.Equals, true)
+}
+
+func (s *S) TestCachedReaderGetLeaders(c *gocheck.C) {
+	client := newCachingClient(c)
+	defer client.Close()
+	board, _ := New(redisSettings, "cachedLeaders", WithPageSize(5), WithSortOrder(LowToHigh))
+	reader := NewCachedReader(board, client, time.Minute)
+	for i := 0; i < 8; i++ {
+		board.RankMember("member_"+strconv.Itoa(i), 100+i)
+	}
+	ctx := context.Background()
+	users, err := reader.GetLeaders(ctx, 2)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(users), gocheck.Equals, 5)
+	c.Assert(users[0].Name, gocheck.Equals, "member_5")
+	c.Assert(users[0].Rank, gocheck.Equals, 6)
+	c.Assert(users[3].Rank, gocheck.Equals, 0)
+	c.Assert(client.DoCache(ctx, reader.cachedRange(5, 9), time.Minute).IsCacheHit(), gocheck.Equals, true)
+
+	board.RankMember("member_0", 200)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(client.DoCache(ctx, reader.cachedRange(5, 9), time.Minute).IsCacheHit(), gocheck.Equals, false)
+	users, _ = reader.GetLeaders(ctx, 2)
+	c.Assert(users[0].Name, gocheck.Equals, "member_6")
+	c.Assert(users[2].Name, gocheck.Equals, "member_0")
+}
+
+func (s *S) TestCachedReaderWithGhosts(c *gocheck.C) {
+	client := newCachingClient(c)
+	defer client.Close()
+	conn := getConnection(redisSettings, SystemClock)
+	defer conn.Close()
+	ctx := context.Background()
+	for _, policy := range []GhostPolicy{GhostsRanked, GhostsUnranked} {
+		for _, ties := range []TiePolicy{TieByMember, TieShared} {
+			board, _ := New(redisSettings, "cachedGhosts", WithPageSize(3), WithGhostPolicy(policy), WithTiePolicy(ties))
+			conn.Do("DEL", board.key(), board.ghostsKey())
+			reader := NewCachedReader(board, client, time.Minute)
+			board.AddGhost("developer", 100)
+			board.AddGhost("tester", 80)
+			board.RankMember("dayvson", 120)
+			board.RankMember("felipe", 100)
+			board.RankMember("arthur", 90)
+			time.Sleep(50 * time.Millisecond)
+			for page := 1; page <= 2; page++ {
+				users, err := reader.GetLeaders(ctx, page)
+				c.Assert(err, gocheck.IsNil)
+				c.Assert(users, gocheck.DeepEquals, board.GetLeaders(page))
+			}
+			for _, member := range []string{"developer", "felipe", "arthur"} {
+				user, err := reader.GetMember(ctx, member)
+				c.Assert(err, gocheck.IsNil)
+				expected, _ := board.GetMember(member)
+				c.Assert(user, gocheck.DeepEquals, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package leaderboard
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Clock tells the time to the time-dependent parts of the package, so that
+// period boundaries, TTLs and schedules can be driven from tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+// ManualClock is a Clock that only moves when told to. Channels returned by
+// After fire once the clock is advanced past their deadline.
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+// clockedConn records when a pooled connection was last used, by the clock
+// of its pool.
+type clockedConn struct {
+	redis.Conn
+	clock Clock
+	used  time.Time
+}
+
+/* End Structs model */
+
+var errIdleTimeout = errors.New("leaderboard: idle connection timed out")
+
+// SystemClock reads the time from the operating system.
+var SystemClock Clock = systemClock{}
+
+/* Private functions */
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// fire releases the waiters whose deadline has passed. The caller holds mu.
+func (c *ManualClock) fire() {
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.deadline.After(c.now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.c <- c.now
+	}
+	c.waiters = pending
+}
+
+func (c *clockedConn) Do(command string, args ...interface{}) (interface{}, error) {
+	c.used = c.clock.Now()
+	return c.Conn.Do(command, args...)
+}
+
+func (c *clockedConn) Send(command string, args ...interface{}) error {
+	c.used = c.clock.Now()
+	return c.Conn.Send(command, args...)
+}
+
+func (c *clockedConn) Receive() (interface{}, error) {
+	c.used = c.clock.Now()
+	return c.Conn.Receive()
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	waiter := manualWaiter{deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, waiter)
+	c.fire()
+	return waiter.c
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to now.
+func (c *ManualClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+	c.fire()
+}
+
+// Waiters returns how many channels returned by After have not fired yet,
+// letting tests wait for a goroutine to block on the clock.
+func (c *ManualClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+/* End Public functions */
@@ -0,0 +1,82 @@
+package leaderboard
+
+import (
+	"context"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestManualClock(c *gocheck.C) {
+	start := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	fired := clock.After(time.Minute)
+	c.Assert(clock.Waiters(), gocheck.Equals, 1)
+	clock.Advance(59 * time.Second)
+	select {
+	case <-fired:
+		c.Fatal("fired before its deadline")
+	default:
+	}
+	clock.Advance(time.Second)
+	c.Assert(<-fired, gocheck.Equals, start.Add(time.Minute))
+	c.Assert(clock.Waiters(), gocheck.Equals, 0)
+	c.Assert(len(clock.After(0)), gocheck.Equals, 1)
+}
+
+func (s *S) TestSchedulerWithManualClock(c *gocheck.C) {
+	start := time.Date(2013, 5, 1, 23, 30, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	daily := NewStreakBoard(redisSettings, "clockStreak", 10, 24*time.Hour, time.UTC)
+	daily.RecordActivity("dayvson", start.Add(-24*time.Hour))
+	daily.RecordActivity("felipe", start)
+
+	scheduler := &Scheduler{Settings: redisSettings, KeyPrefix: "test:", Clock: clock}
+	schedule, _ := Every(24 * time.Hour)
+	job := daily.SweepJob(schedule)
+	runs := make(chan JobRun, 1)
+	scheduler.Add(Job{Name: job.Name, Schedule: job.Schedule, Run: func(ctx context.Context, run JobRun) error {
+		err := job.Run(ctx, run)
+		runs <- run
+		return err
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Hour)
+	run := <-runs
+	c.Assert(run.Time, gocheck.Equals, time.Date(2013, 5, 2, 0, 0, 0, 0, time.UTC))
+	current := daily.Current()
+	c.Assert(current.TotalMembers(), gocheck.Equals, 1)
+	c.Assert(current.GetRank("felipe"), gocheck.Equals, 1)
+}
+
+func (s *S) TestPoolIdleTimeoutFollowsClock(c *gocheck.C) {
+	server, err := miniredis.Run()
+	c.Assert(err, gocheck.IsNil)
+	s.servers = append(s.servers, server)
+	clock := NewManualClock(time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC))
+	pool := newPool(server.Addr(), "", clock)
+	defer pool.Close()
+
+	conn := pool.Get()
+	conn.Do("PING")
+	conn.Close()
+	clock.Advance(poolIdleTimeout)
+	conn = pool.Get()
+	conn.Do("PING")
+	conn.Close()
+	c.Assert(server.TotalConnectionCount(), gocheck.Equals, 1)
+
+	clock.Advance(poolIdleTimeout + time.Second)
+	conn = pool.Get()
+	_, err = conn.Do("PING")
+	conn.Close()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(server.TotalConnectionCount(), gocheck.Equals, 2)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dayvson/go-leaderboard"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+/* Structs model */
+
+// exportOptions holds the flags of the export command.
+type exportOptions struct {
+	config    string
+	host      string
+	password  string
+	prefix    string
+	listen    string
+	interval  time.Duration
+	quantiles []float64
+}
+
+/* End Structs model */
+
+/* Private functions */
+
+func parseExport(args []string, stderr io.Writer) (exportOptions, []string, error) {
+	o := exportOptions{}
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&o.config, "config", "", "config file defining the boards and the Redis settings")
+	fs.StringVar(&o.host, "host", "localhost:6379", "Redis address, without -config")
+	fs.StringVar(&o.password, "password", "", "Redis password, without -config")
+	fs.StringVar(&o.prefix, "prefix", "", "key prefix of the boards, without -config")
+	fs.StringVar(&o.listen, "listen", ":9150", "address serving the metrics on /metrics")
+	fs.DurationVar(&o.interval, "interval", 15*time.Second, "time between two samples of the boards")
+	quantiles := fs.String("quantiles", "0.5,0.9,0.99", "comma separated score quantiles exported")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: leaderboard export [flags] [board...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return o, nil, err
+	}
+	if o.config == "" && fs.NArg() == 0 {
+		fs.Usage()
+		return o, nil, fmt.Errorf("leaderboard: export takes board names without -config")
+	}
+	for _, quantile := range strings.Split(*quantiles, ",") {
+		if quantile = strings.TrimSpace(quantile); quantile == "" {
+			continue
+		}
+		q, err := strconv.ParseFloat(quantile, 64)
+		if err != nil {
+			return o, nil, fmt.Errorf("leaderboard: invalid quantile %q", quantile)
+		}
+		o.quantiles = append(o.quantiles, q)
+	}
+	return o, fs.Args(), nil
+}
+
+// openRegistry returns the boards to export: the boards of the config, or
+// only the named ones when names are given.
+func openRegistry(o exportOptions, names []string) (*leaderboard.Registry, error) {
+	if o.config == "" {
+		registry := leaderboard.NewRegistry()
+		settings := leaderboard.RedisSettings{Host: o.host, Password: o.password}
+		for _, name := range names {
+			board, err := leaderboard.New(settings, name, leaderboard.WithKeyPrefix(o.prefix))
+			if err != nil {
+				return nil, err
+			}
+			if err := registry.Register(board); err != nil {
+				return nil, err
+			}
+		}
+		return registry, nil
+	}
+	config, err := leaderboard.LoadConfig(o.config)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := config.Registry()
+	if err != nil || len(names) == 0 {
+		return registry, err
+	}
+	selected := leaderboard.NewRegistry()
+	for _, name := range names {
+		board, ok := registry.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("leaderboard: %q is not defined in %s", name, o.config)
+		}
+		if err := selected.Register(board); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
+
+// metricsHandler serves the metrics of exporter in the Prometheus text
+// format.
+func metricsHandler(exporter *leaderboard.Exporter) (http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(exporter); err != nil {
+		return nil, err
+	}
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
+}
+
+// export runs the export command.
+func export(args []string, stdout io.Writer, stderr io.Writer) int {
+	o, names, err := parseExport(args, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	registry, err := openRegistry(o, names)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	exporter, err := leaderboard.NewExporter(registry, o.interval, o.quantiles...)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	exporter.Logger = log.New(stderr, "", log.LstdFlags)
+	handler, err := metricsHandler(exporter)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	server := &http.Server{Addr: o.listen, Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go exporter.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	fmt.Fprintf(stdout, "exporting %d boards on %s/metrics\n", len(registry.Boards()), o.listen)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+/* End Private functions */
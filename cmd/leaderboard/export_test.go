@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dayvson/go-leaderboard"
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestExportMetrics(c *gocheck.C) {
+	settings := leaderboard.RedisSettings{Host: "localhost:6379"}
+	board := leaderboard.NewLeaderboard(settings, "exported", 10)
+	board.RankMember("dayvson", 10)
+	board.RankMember("felipe", 20)
+	defer func() {
+		conn, _ := redis.Dial("tcp", settings.Host)
+		conn.Do("DEL", "exported")
+		conn.Close()
+	}()
+
+	dir := c.MkDir()
+	path := filepath.Join(dir, "boards.yaml")
+	config := "redis:\n  host: localhost:6379\nboards:\n  - name: exported\n  - name: other\n"
+	c.Assert(ioutil.WriteFile(path, []byte(config), 0644), gocheck.IsNil)
+
+	o, names, err := parseExport([]string{"-config", path, "-quantiles", "0.5, 1", "exported"}, ioutil.Discard)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(o.quantiles, gocheck.DeepEquals, []float64{0.5, 1})
+	registry, err := openRegistry(o, names)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(registry.Boards()), gocheck.Equals, 1)
+	exporter, err := leaderboard.NewExporter(registry, time.Second, o.quantiles...)
+	c.Assert(err, gocheck.IsNil)
+	handler, err := metricsHandler(exporter)
+	c.Assert(err, gocheck.IsNil)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	c.Assert(strings.Contains(body, `leaderboard_members{board="exported"} 2`), gocheck.Equals, true)
+	c.Assert(strings.Contains(body, `leaderboard_top_score{board="exported"} 20`), gocheck.Equals, true)
+	c.Assert(strings.Contains(body, `leaderboard_score{board="exported",quantile="0.5"} 10`), gocheck.Equals, true)
+	c.Assert(strings.Contains(body, `board="other"`), gocheck.Equals, false)
+
+	_, err = openRegistry(exportOptions{config: path}, []string{"missing"})
+	c.Assert(err, gocheck.ErrorMatches, `leaderboard: "missing" is not defined in .*`)
+	var stdout, stderr bytes.Buffer
+	c.Assert(run([]string{"export"}, &stdout, &stderr), gocheck.Equals, 2)
+	c.Assert(run([]string{"export", "-quantiles", "high", "exported"}, &stdout, &stderr), gocheck.Equals, 2)
+	c.Assert(run([]string{"export", "-interval", "0", "exported"}, &stdout, &stderr), gocheck.Equals, 2)
+}
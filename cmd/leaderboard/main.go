@@ -0,0 +1,51 @@
+// Command leaderboard operates leaderboards stored in Redis.
+//
+// Usage:
+//
+//	leaderboard watch [flags] <board>
+//	leaderboard export [flags] [board...]
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+/* Private functions */
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: leaderboard <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  watch    show the top of a board, refreshed as it changes")
+	fmt.Fprintln(w, "  export   serve statistics of boards as Prometheus metrics")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run \"leaderboard <command> -h\" for the flags of a command")
+}
+
+// run executes the command line args and returns the exit code.
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "watch":
+		return watch(args[1:], stdout, stderr)
+	case "export":
+		return export(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+	fmt.Fprintf(stderr, "leaderboard: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+/* End Private functions */
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
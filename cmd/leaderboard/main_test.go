@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"launchpad.net/gocheck"
+)
+
+func Test(t *testing.T) {
+	gocheck.TestingT(t)
+}
+
+type S struct{}
+
+var _ = gocheck.Suite(&S{})
+
+func (s *S) TestRunUnknownCommand(c *gocheck.C) {
+	var stdout, stderr bytes.Buffer
+	c.Assert(run([]string{"dance"}, &stdout, &stderr), gocheck.Equals, 2)
+	c.Assert(stderr.String(), gocheck.Matches, `(?s)leaderboard: unknown command "dance".*watch.*`)
+	c.Assert(run(nil, &stdout, &stderr), gocheck.Equals, 2)
+	c.Assert(run([]string{"help"}, &stdout, &stderr), gocheck.Equals, 0)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/dayvson/go-leaderboard"
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// watchOptions holds the flags of the watch command and the clock timing
+// the refreshes.
+type watchOptions struct {
+	config   string
+	host     string
+	password string
+	prefix   string
+	top      int
+	members  []string
+	interval time.Duration
+	events   bool
+	once     bool
+	color    bool
+	clock    leaderboard.Clock
+}
+
+// frame is what a refresh of the watch command shows.
+type frame struct {
+	board   string
+	at      time.Time
+	top     []leaderboard.User
+	members []leaderboard.User
+}
+
+/* End Structs model */
+
+const (
+	clearScreen = "\033[H\033[2J"
+	bold        = "\033[1m"
+	green       = "\033[32m"
+	red         = "\033[31m"
+	yellow      = "\033[33m"
+	reset       = "\033[0m"
+)
+
+/* Private functions */
+
+func parseWatch(args []string, stderr io.Writer) (watchOptions, string, error) {
+	o := watchOptions{clock: leaderboard.SystemClock}
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&o.config, "config", "", "config file defining the board and the Redis settings")
+	fs.StringVar(&o.host, "host", "localhost:6379", "Redis address, without -config")
+	fs.StringVar(&o.password, "password", "", "Redis password, without -config")
+	fs.StringVar(&o.prefix, "prefix", "", "key prefix of the board, without -config")
+	fs.IntVar(&o.top, "n", 10, "number of leaders shown")
+	members := fs.String("members", "", "comma separated members shown below the leaders")
+	fs.DurationVar(&o.interval, "interval", time.Second, "time between two refreshes")
+	fs.BoolVar(&o.events, "events", false, "refresh on keyspace notifications too (needs notify-keyspace-events with K and z)")
+	fs.BoolVar(&o.once, "once", false, "show the board once and exit")
+	plain := fs.Bool("plain", false, "no colors nor screen clearing")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: leaderboard watch [flags] <board>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return o, "", err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return o, "", fmt.Errorf("leaderboard: watch takes a single board name")
+	}
+	if o.top < 1 {
+		return o, "", fmt.Errorf("leaderboard: -n must be positive, got %d", o.top)
+	}
+	if o.interval < 10*time.Millisecond {
+		return o, "", fmt.Errorf("leaderboard: -interval must be at least 10ms, got %s", o.interval)
+	}
+	for _, member := range strings.Split(*members, ",") {
+		if member = strings.TrimSpace(member); member != "" {
+			o.members = append(o.members, member)
+		}
+	}
+	o.color = !*plain
+	return o, fs.Arg(0), nil
+}
+
+// openBoard returns the board to watch with a page of the leaders shown, and
+// the Redis settings and key it is stored with.
+func openBoard(o watchOptions, name string) (leaderboard.Leaderboard, leaderboard.RedisSettings, string, error) {
+	settings := leaderboard.RedisSettings{Host: o.host, Password: o.password}
+	prefix := o.prefix
+	var board leaderboard.Leaderboard
+	if o.config != "" {
+		config, err := leaderboard.LoadConfig(o.config)
+		if err != nil {
+			return board, settings, "", err
+		}
+		registry, err := config.Registry()
+		if err != nil {
+			return board, settings, "", err
+		}
+		var ok bool
+		if board, ok = registry.Get(name); !ok {
+			return board, settings, "", fmt.Errorf("leaderboard: %q is not defined in %s", name, o.config)
+		}
+		settings = leaderboard.RedisSettings{Host: config.Redis.Host, Password: config.Redis.Password}
+		prefix = config.Redis.KeyPrefix
+	} else {
+		var err error
+		board, err = leaderboard.New(settings, name, leaderboard.WithKeyPrefix(prefix))
+		if err != nil {
+			return board, settings, "", err
+		}
+	}
+	board.PageSize = o.top
+	return board, settings, prefix + name, nil
+}
+
+// capture reads the leaders and the members shown in a single round trip.
+// Members not ranked are shown without a rank.
+func capture(board *leaderboard.Leaderboard, members []string, at time.Time) (frame, error) {
+	f := frame{board: board.Name, at: at, top: []leaderboard.User{}}
+	batch := leaderboard.NewBatch()
+	leaders := batch.GetLeaders(board, 1)
+	futures := make([]*leaderboard.MemberFuture, len(members))
+	for i, member := range members {
+		futures[i] = batch.GetMember(board, member)
+	}
+	if err := batch.Exec(); err != nil {
+		return f, err
+	}
+	users, err := leaders.Result()
+	if err != nil {
+		return f, err
+	}
+	for _, user := range users {
+		if user.Name != "" {
+			f.top = append(f.top, user)
+		}
+	}
+	for _, future := range futures {
+		user, err := future.Result()
+		if err != nil && err != redis.ErrNil {
+			return f, err
+		}
+		f.members = append(f.members, user)
+	}
+	return f, nil
+}
+
+// change describes how the rank of a member moved since the previous frame,
+// with the color to show it in.
+func change(user leaderboard.User, previous map[string]int) (string, string) {
+	before, seen := previous[user.Name]
+	switch {
+	case user.Rank == 0:
+		return "", ""
+	case !seen || before == 0:
+		return "new", yellow
+	case user.Rank < before:
+		return fmt.Sprintf("▲%d", before-user.Rank), green
+	case user.Rank > before:
+		return fmt.Sprintf("▼%d", user.Rank-before), red
+	}
+	return "", ""
+}
+
+// render writes a frame as a table, highlighting the rank changes since the
+// previous frame, and returns the ranks it shows.
+func render(w io.Writer, f frame, previous map[string]int, color bool) map[string]int {
+	ranks := map[string]int{}
+	paint := func(text string, code string) string {
+		if !color || code == "" {
+			return text
+		}
+		return code + text + reset
+	}
+	if color {
+		fmt.Fprint(w, clearScreen)
+	}
+	fmt.Fprintln(w, paint(f.board, bold)+"  "+f.at.Format("15:04:05"))
+	row := func(user leaderboard.User) {
+		rank := "-"
+		if user.Rank > 0 {
+			rank = fmt.Sprint(user.Rank)
+		}
+		move, code := change(user, previous)
+		name := user.Name
+		if user.Ghost {
+			name += " (ghost)"
+		}
+		fmt.Fprintf(w, "%6s  %-24s %12d  %s\n", rank, name, user.Score, paint(move, code))
+		ranks[user.Name] = user.Rank
+	}
+	fmt.Fprintf(w, "%6s  %-24s %12s\n", "RANK", "MEMBER", "SCORE")
+	for _, user := range f.top {
+		row(user)
+	}
+	if len(f.top) == 0 {
+		fmt.Fprintln(w, "  (empty)")
+	}
+	if len(f.members) > 0 {
+		fmt.Fprintln(w, strings.Repeat("-", 48))
+		for _, user := range f.members {
+			row(user)
+		}
+	}
+	return ranks
+}
+
+// keyspacePattern matches the keyspace notifications of key in any database,
+// glob characters of key matching only themselves.
+func keyspacePattern(key string) string {
+	return "__keyspace@*__:" + leaderboard.EscapePattern(key)
+}
+
+// subscribe sends on the returned channel whenever the board key changes,
+// until ctx is done.
+func subscribe(ctx context.Context, settings leaderboard.RedisSettings, key string) (<-chan struct{}, error) {
+	conn, err := redis.Dial("tcp", settings.Host, redis.DialPassword(settings.Password))
+	if err != nil {
+		return nil, err
+	}
+	psc := redis.PubSubConn{Conn: conn}
+	if err := psc.PSubscribe(keyspacePattern(key)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	changes := make(chan struct{}, 1)
+	go func() {
+		<-ctx.Done()
+		psc.PUnsubscribe()
+		conn.Close()
+	}()
+	go func() {
+		for {
+			switch psc.Receive().(type) {
+			case redis.PMessage:
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			case error:
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// refresh renders the board, or writes to stderr why it could not be read,
+// and returns the ranks shown.
+func refresh(o watchOptions, board *leaderboard.Leaderboard, previous map[string]int, stdout io.Writer, stderr io.Writer) (map[string]int, error) {
+	f, err := capture(board, o.members, o.clock.Now())
+	if err != nil {
+		fmt.Fprintf(stderr, "leaderboard: cannot read %s: %s\n", board.Name, err)
+		return previous, err
+	}
+	return render(stdout, f, previous, o.color), nil
+}
+
+// follow refreshes the board every interval of the clock of o, and on every
+// change when changes is not nil, until ctx is done.
+func follow(ctx context.Context, o watchOptions, board *leaderboard.Leaderboard, changes <-chan struct{}, previous map[string]int, stdout io.Writer, stderr io.Writer) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.clock.After(o.interval):
+		case <-changes:
+		}
+		previous, _ = refresh(o, board, previous, stdout, stderr)
+	}
+}
+
+// watch runs the watch command.
+func watch(args []string, stdout io.Writer, stderr io.Writer) int {
+	o, name, err := parseWatch(args, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	board, settings, key, err := openBoard(o, name)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	previous, err := refresh(o, &board, map[string]int{}, stdout, stderr)
+	if o.once {
+		if err != nil {
+			return 1
+		}
+		return 0
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var changes <-chan struct{}
+	if o.events {
+		if changes, err = subscribe(ctx, settings, key); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	follow(ctx, o, &board, changes, previous, stdout, stderr)
+	return 0
+}
+
+/* End Private functions */
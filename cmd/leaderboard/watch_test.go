@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dayvson/go-leaderboard"
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestRenderHighlightsRankChanges(c *gocheck.C) {
+	f := frame{
+		board: "highscores",
+		at:    time.Date(2013, 5, 1, 12, 30, 0, 0, time.UTC),
+		top: []leaderboard.User{
+			{Name: "felipe", Score: 300, Rank: 1},
+			{Name: "dayvson", Score: 200, Rank: 2},
+			{Name: "arthur", Score: 100, Rank: 3},
+		},
+		members: []leaderboard.User{{Name: "missing"}},
+	}
+	var out bytes.Buffer
+	ranks := render(&out, f, map[string]int{"dayvson": 1, "felipe": 3, "arthur": 3}, false)
+	c.Assert(ranks, gocheck.DeepEquals, map[string]int{"felipe": 1, "dayvson": 2, "arthur": 3, "missing": 0})
+	lines := strings.Split(out.String(), "\n")
+	c.Assert(lines[0], gocheck.Equals, "highscores  12:30:00")
+	c.Assert(lines[2], gocheck.Matches, `\s+1  felipe\s+300  ▲2`)
+	c.Assert(lines[3], gocheck.Matches, `\s+2  dayvson\s+200  ▼1`)
+	c.Assert(lines[4], gocheck.Matches, `\s+3  arthur\s+100  `)
+	c.Assert(lines[6], gocheck.Matches, `\s+-  missing\s+0  `)
+
+	out.Reset()
+	render(&out, f, map[string]int{}, true)
+	c.Assert(strings.HasPrefix(out.String(), clearScreen), gocheck.Equals, true)
+	c.Assert(strings.Contains(out.String(), yellow+"new"+reset), gocheck.Equals, true)
+}
+
+func (s *S) TestWatchOnce(c *gocheck.C) {
+	settings := leaderboard.RedisSettings{Host: "localhost:6379"}
+	board := leaderboard.NewLeaderboard(settings, "watched", 10)
+	board.RankMember("dayvson", 10)
+	board.RankMember("felipe", 20)
+	board.RankMember("arthur", 5)
+	defer func() {
+		conn, _ := redis.Dial("tcp", settings.Host)
+		conn.Do("DEL", "watched")
+		conn.Close()
+	}()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"watch", "-once", "-plain", "-n", "2", "-members", "arthur", "watched"}, &stdout, &stderr)
+	c.Assert(code, gocheck.Equals, 0)
+	c.Assert(stderr.String(), gocheck.Equals, "")
+	lines := strings.Split(stdout.String(), "\n")
+	c.Assert(lines[2], gocheck.Matches, `\s+1  felipe\s+20  new`)
+	c.Assert(lines[3], gocheck.Matches, `\s+2  dayvson\s+10  new`)
+	c.Assert(lines[4], gocheck.Matches, `-+`)
+	c.Assert(lines[5], gocheck.Matches, `\s+3  arthur\s+5  new`)
+
+	c.Assert(run([]string{"watch", "-n", "0", "watched"}, &stdout, &stderr), gocheck.Equals, 2)
+	c.Assert(run([]string{"watch"}, &stdout, &stderr), gocheck.Equals, 2)
+}
+
+// lockedBuffer is a bytes.Buffer safe for a writer and a reader running
+// concurrently.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (s *S) TestFollowRefreshesOnClock(c *gocheck.C) {
+	settings := leaderboard.RedisSettings{Host: "localhost:6379"}
+	board := leaderboard.NewLeaderboard(settings, "followed", 10)
+	defer func() {
+		conn, _ := redis.Dial("tcp", settings.Host)
+		conn.Do("DEL", "followed")
+		conn.Close()
+	}()
+	clock := leaderboard.NewManualClock(time.Date(2013, 5, 1, 12, 30, 0, 0, time.UTC))
+	o, _, err := parseWatch([]string{"-plain", "-interval", "1m", "followed"}, ioutil.Discard)
+	c.Assert(err, gocheck.IsNil)
+	o.clock = clock
+
+	var out lockedBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go follow(ctx, o, &board, nil, map[string]int{}, &out, ioutil.Discard)
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	board.RankMember("dayvson", 10)
+	clock.Advance(59 * time.Second)
+	c.Assert(out.String(), gocheck.Equals, "")
+	clock.Advance(time.Second)
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	lines := strings.Split(out.String(), "\n")
+	c.Assert(lines[0], gocheck.Equals, "followed  12:31:00")
+	c.Assert(lines[2], gocheck.Matches, `\s+1  dayvson\s+10  new`)
+}
+
+func (s *S) TestWatchReportsReadErrors(c *gocheck.C) {
+	settings := leaderboard.RedisSettings{Host: "localhost:6379"}
+	conn, _ := redis.Dial("tcp", settings.Host)
+	defer conn.Close()
+	conn.Do("SET", "watchedString", "not a leaderboard")
+	defer conn.Do("DEL", "watchedString")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"watch", "-once", "-plain", "watchedString"}, &stdout, &stderr)
+	c.Assert(code, gocheck.Equals, 1)
+	c.Assert(stdout.String(), gocheck.Equals, "")
+	c.Assert(stderr.String(), gocheck.Matches, "leaderboard: cannot read watchedString: .*WRONGTYPE.*\n")
+}
+
+func (s *S) TestKeyspacePatternEscapesKey(c *gocheck.C) {
+	c.Assert(keyspacePattern("game:kills"), gocheck.Equals, "__keyspace@*__:game:kills")
+	c.Assert(keyspacePattern("maps:[desert]*?"), gocheck.Equals, `__keyspace@*__:maps:\[desert\]\*\?`)
+}
@@ -0,0 +1,86 @@
+package leaderboard
+
+import (
+	"errors"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Comparison holds the standing of a group of members on a single leaderboard.
+// RankGaps and ScoreGaps are relative to the first member: a positive rank gap
+// means the member is placed below the first one, a negative score gap means
+// the member scored less. Members absent from the leaderboard have a zero Rank
+// and zero gaps.
+type Comparison struct {
+	Leaderboard string
+	Members     []User
+	RankGaps    []int
+	ScoreGaps   []int
+}
+
+/* End Structs model */
+
+/* Public functions */
+
+// CompareMembers returns the score, rank and gaps of members on every board,
+// fetched in a single pipeline. The boards must share a backend.
+func CompareMembers(boards []Leaderboard, members ...string) ([]Comparison, error) {
+	if len(members) < 2 {
+		return nil, errors.New("leaderboard: at least two members are needed for a comparison")
+	}
+	if len(boards) == 0 {
+		return []Comparison{}, nil
+	}
+	conn := boards[0].conn()
+	defer conn.Close()
+	for _, board := range boards {
+		for _, member := range members {
+			board.sendMember(conn, member)
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+	comparisons := make([]Comparison, len(boards))
+	for b, board := range boards {
+		users := make([]User, len(members))
+		for m, member := range members {
+			user, err := receiveMember(conn, member)
+			if err != nil && err != redis.ErrNil {
+				return nil, err
+			}
+			users[m] = user
+		}
+		comparisons[b] = newComparison(board.Name, users)
+	}
+	return comparisons, nil
+}
+
+/* End Public functions */
+
+/* Private functions */
+
+func newComparison(name string, users []User) Comparison {
+	c := Comparison{
+		Leaderboard: name,
+		Members:     users,
+		RankGaps:    make([]int, len(users)),
+		ScoreGaps:   make([]int, len(users)),
+	}
+	first := users[0]
+	if first.Rank == 0 {
+		return c
+	}
+	for i, user := range users {
+		if user.Rank == 0 {
+			continue
+		}
+		c.RankGaps[i] = user.Rank - first.Rank
+		c.ScoreGaps[i] = user.Score - first.Score
+	}
+	return c
+}
+
+/* End Private functions */
@@ -0,0 +1,34 @@
+package leaderboard
+
+import (
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestCompareMembers(c *gocheck.C) {
+	arena := NewLeaderboard(redisSettings, "compareArena", 10)
+	race := NewLeaderboard(redisSettings, "compareRace", 10)
+	arena.RankMember("dayvson", 300)
+	arena.RankMember("arthur", 100)
+	arena.RankMember("felipe", 200)
+	race.RankMember("arthur", 50)
+
+	comparisons, err := CompareMembers([]Leaderboard{arena, race}, "dayvson", "arthur")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(comparisons), gocheck.Equals, 2)
+
+	c.Assert(comparisons[0].Leaderboard, gocheck.Equals, "compareArena")
+	c.Assert(comparisons[0].Members[0].Rank, gocheck.Equals, 1)
+	c.Assert(comparisons[0].Members[1].Rank, gocheck.Equals, 3)
+	c.Assert(comparisons[0].RankGaps[1], gocheck.Equals, 2)
+	c.Assert(comparisons[0].ScoreGaps[1], gocheck.Equals, -200)
+
+	c.Assert(comparisons[1].Members[0].Rank, gocheck.Equals, 0)
+	c.Assert(comparisons[1].Members[1].Score, gocheck.Equals, 50)
+	c.Assert(comparisons[1].RankGaps[1], gocheck.Equals, 0)
+}
+
+func (s *S) TestCompareMembersNeedsTwoMembers(c *gocheck.C) {
+	arena := NewLeaderboard(redisSettings, "compareArena", 10)
+	_, err := CompareMembers([]Leaderboard{arena}, "dayvson")
+	c.Assert(err, gocheck.NotNil)
+}
@@ -0,0 +1,317 @@
+package leaderboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+/* Structs model */
+
+// Config describes a Redis deployment and the leaderboards stored in it. It
+// can be read from YAML, JSON or TOML.
+type Config struct {
+	Redis  RedisConfig   `json:"redis" yaml:"redis" toml:"redis"`
+	Boards []BoardConfig `json:"boards" yaml:"boards" toml:"boards"`
+}
+
+type RedisConfig struct {
+	Host      string `json:"host" yaml:"host" toml:"host"`
+	Password  string `json:"password" yaml:"password" toml:"password"`
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix" toml:"key_prefix"`
+}
+
+// BoardConfig describes a leaderboard. SortOrder is "high_to_low" (default)
+// or "low_to_high", Ties is "by_member" (default) or "shared". Parent names
+// a board defined earlier that the board rolls up into, and Aggregation,
+// "best" (default) or "sum", how the board combines its children. Windows
+// lists the periods, "daily", "weekly" or "monthly", the board is also
+// ranked over, Segments names metadata filters and Cap, when positive, is
+// the number of members kept.
+type BoardConfig struct {
+	Name        string            `json:"name" yaml:"name" toml:"name"`
+	SortOrder   string            `json:"sort_order" yaml:"sort_order" toml:"sort_order"`
+	PageSize    int               `json:"page_size" yaml:"page_size" toml:"page_size"`
+	Ties        string            `json:"ties" yaml:"ties" toml:"ties"`
+	Parent      string            `json:"parent" yaml:"parent" toml:"parent"`
+	Aggregation string            `json:"aggregation" yaml:"aggregation" toml:"aggregation"`
+	Windows     []string          `json:"windows" yaml:"windows" toml:"windows"`
+	Segments    map[string]Filter `json:"segments" yaml:"segments" toml:"segments"`
+	Cap         int               `json:"cap" yaml:"cap" toml:"cap"`
+}
+
+// ConfigError points at the field of a Config that failed validation, e.g.
+// "boards[1].page_size".
+type ConfigError struct {
+	Field   string
+	Message string
+}
+
+// ConfigErrors lists every invalid field of a Config.
+type ConfigErrors []ConfigError
+
+/* End Structs model */
+
+var sortOrders = map[string]SortOrder{"": HighToLow, "high_to_low": HighToLow, "low_to_high": LowToHigh}
+var tiePolicies = map[string]TiePolicy{"": TieByMember, "by_member": TieByMember, "shared": TieShared}
+var aggregations = map[string]Aggregation{"": AggregateBest, "best": AggregateBest, "sum": AggregateSum}
+var windowPeriods = map[string]Window{"daily": WindowDaily, "weekly": WindowWeekly, "monthly": WindowMonthly}
+
+/* Private functions */
+
+// envName turns a board name into the form used in environment variables,
+// e.g. "best-time" into "BEST_TIME".
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+func (e ConfigError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "leaderboard: invalid config: " + strings.Join(messages, "; ")
+}
+
+// LoadConfig reads a config file, picking the format from its extension
+// (.yaml, .yml, .json or .toml), applies the environment overrides and
+// validates the result.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseConfig(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ParseConfig decodes a config in the given format: "yaml", "yml", "json" or
+// "toml". Unknown fields are rejected.
+func ParseConfig(data []byte, format string) (*Config, error) {
+	c := &Config{}
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		if err := yaml.UnmarshalStrict(data, c); err != nil {
+			return nil, err
+		}
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(c); err != nil {
+			return nil, err
+		}
+	case "toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return nil, err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("leaderboard: unknown config field %s", undecoded[0])
+		}
+	default:
+		return nil, fmt.Errorf("leaderboard: unknown config format %q", format)
+	}
+	return c, nil
+}
+
+// ApplyEnv overrides the config with environment variables:
+//
+//	LEADERBOARD_REDIS_HOST, LEADERBOARD_REDIS_PASSWORD, LEADERBOARD_KEY_PREFIX
+//	LEADERBOARD_<BOARD>_SORT_ORDER, LEADERBOARD_<BOARD>_PAGE_SIZE, LEADERBOARD_<BOARD>_TIES
+//	LEADERBOARD_<BOARD>_PARENT, LEADERBOARD_<BOARD>_AGGREGATION
+//	LEADERBOARD_<BOARD>_WINDOWS, LEADERBOARD_<BOARD>_CAP
+//
+// where <BOARD> is the board name in upper case with any other character than
+// letters and digits replaced by "_". Windows are separated by commas. An
+// empty parent detaches the board. Segments have no variables: a filter does
+// not fit one, so they are only read from the file.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	if value, ok := lookup("LEADERBOARD_REDIS_HOST"); ok {
+		c.Redis.Host = value
+	}
+	if value, ok := lookup("LEADERBOARD_REDIS_PASSWORD"); ok {
+		c.Redis.Password = value
+	}
+	if value, ok := lookup("LEADERBOARD_KEY_PREFIX"); ok {
+		c.Redis.KeyPrefix = value
+	}
+	for i := range c.Boards {
+		board := &c.Boards[i]
+		prefix := "LEADERBOARD_" + envName(board.Name) + "_"
+		if value, ok := lookup(prefix + "SORT_ORDER"); ok {
+			board.SortOrder = value
+		}
+		if value, ok := lookup(prefix + "TIES"); ok {
+			board.Ties = value
+		}
+		if value, ok := lookup(prefix + "PARENT"); ok {
+			board.Parent = value
+		}
+		if value, ok := lookup(prefix + "AGGREGATION"); ok {
+			board.Aggregation = value
+		}
+		if value, ok := lookup(prefix + "PAGE_SIZE"); ok {
+			pageSize, err := strconv.Atoi(value)
+			if err != nil {
+				return ConfigErrors{{Field: prefix + "PAGE_SIZE", Message: "must be an integer"}}
+			}
+			board.PageSize = pageSize
+		}
+		if value, ok := lookup(prefix + "WINDOWS"); ok {
+			board.Windows = nil
+			for _, window := range strings.Split(value, ",") {
+				if window = strings.TrimSpace(window); window != "" {
+					board.Windows = append(board.Windows, window)
+				}
+			}
+		}
+		if value, ok := lookup(prefix + "CAP"); ok {
+			members, err := strconv.Atoi(value)
+			if err != nil {
+				return ConfigErrors{{Field: prefix + "CAP", Message: "must be an integer"}}
+			}
+			board.Cap = members
+		}
+	}
+	return nil
+}
+
+// Validate checks every field of the config and returns ConfigErrors listing
+// the invalid ones.
+func (c *Config) Validate() error {
+	var errs ConfigErrors
+	if c.Redis.Host == "" {
+		errs = append(errs, ConfigError{Field: "redis.host", Message: "must not be empty"})
+	}
+	seen := map[string]bool{}
+	for i, board := range c.Boards {
+		field := fmt.Sprintf("boards[%d].", i)
+		if board.Name == "" {
+			errs = append(errs, ConfigError{Field: field + "name", Message: "must not be empty"})
+		} else if seen[board.Name] {
+			errs = append(errs, ConfigError{Field: field + "name", Message: fmt.Sprintf("%q is defined twice", board.Name)})
+		}
+		seen[board.Name] = true
+		if board.PageSize < 0 {
+			errs = append(errs, ConfigError{Field: field + "page_size", Message: "must not be negative"})
+		}
+		if _, ok := sortOrders[board.SortOrder]; !ok {
+			errs = append(errs, ConfigError{Field: field + "sort_order", Message: fmt.Sprintf("unknown sort order %q", board.SortOrder)})
+		}
+		if _, ok := tiePolicies[board.Ties]; !ok {
+			errs = append(errs, ConfigError{Field: field + "ties", Message: fmt.Sprintf("unknown tie policy %q", board.Ties)})
+		}
+		if board.Parent != "" && (!seen[board.Parent] || board.Parent == board.Name) {
+			errs = append(errs, ConfigError{Field: field + "parent", Message: fmt.Sprintf("%q must be defined before its children", board.Parent)})
+		}
+		if _, ok := aggregations[board.Aggregation]; !ok {
+			errs = append(errs, ConfigError{Field: field + "aggregation", Message: fmt.Sprintf("unknown aggregation %q", board.Aggregation)})
+		}
+		for j, window := range board.Windows {
+			if _, ok := windowPeriods[window]; !ok {
+				errs = append(errs, ConfigError{Field: fmt.Sprintf("%swindows[%d]", field, j), Message: fmt.Sprintf("unknown window %q", window)})
+			}
+		}
+		var empty []string
+		for name, filter := range board.Segments {
+			if name == "" {
+				empty = append(empty, field+"segments")
+			}
+			for key, values := range filter {
+				if len(values) == 0 {
+					empty = append(empty, field+"segments."+name+"."+key)
+				}
+			}
+		}
+		sort.Strings(empty)
+		for _, emptyField := range empty {
+			errs = append(errs, ConfigError{Field: emptyField, Message: "must not be empty"})
+		}
+		if board.Cap < 0 {
+			errs = append(errs, ConfigError{Field: field + "cap", Message: "must not be negative"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Registry creates the leaderboards of the config. Options are applied to
+// every leaderboard before the settings of the config.
+func (c *Config) Registry(options ...Option) (*Registry, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	settings := RedisSettings{Host: c.Redis.Host, Password: c.Redis.Password}
+	r := NewRegistry()
+	for _, board := range c.Boards {
+		boardOptions := append([]Option{}, options...)
+		boardOptions = append(boardOptions, WithSortOrder(sortOrders[board.SortOrder]), WithTiePolicy(tiePolicies[board.Ties]),
+			WithAggregation(aggregations[board.Aggregation]))
+		if board.PageSize > 0 {
+			boardOptions = append(boardOptions, WithPageSize(board.PageSize))
+		}
+		for _, window := range board.Windows {
+			boardOptions = append(boardOptions, WithWindows(windowPeriods[window]))
+		}
+		for name, filter := range board.Segments {
+			boardOptions = append(boardOptions, WithSegment(name, filter))
+		}
+		if board.Cap > 0 {
+			boardOptions = append(boardOptions, WithCap(board.Cap))
+		}
+		if c.Redis.KeyPrefix != "" {
+			boardOptions = append(boardOptions, WithKeyPrefix(c.Redis.KeyPrefix))
+		}
+		l, err := New(settings, board.Name, boardOptions...)
+		if err != nil {
+			return nil, err
+		}
+		if board.Parent != "" {
+			err = r.RegisterChild(board.Parent, l)
+		} else {
+			err = r.Register(l)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+/* End Public functions */
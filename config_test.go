@@ -0,0 +1,201 @@
+package leaderboard
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"launchpad.net/gocheck"
+)
+
+const yamlConfig = `
+redis:
+  host: localhost:6379
+  key_prefix: "game:"
+boards:
+  - name: highscore
+    page_size: 10
+  - name: best-time
+    sort_order: low_to_high
+    ties: shared
+`
+
+func (s *S) TestParseConfig(c *gocheck.C) {
+	formats := map[string]string{
+		"yaml": yamlConfig,
+		"json": `{"redis": {"host": "localhost:6379", "key_prefix": "game:"},
+			"boards": [{"name": "highscore", "page_size": 10},
+				{"name": "best-time", "sort_order": "low_to_high", "ties": "shared"}]}`,
+		"toml": `
+[redis]
+host = "localhost:6379"
+key_prefix = "game:"
+
+[[boards]]
+name = "highscore"
+page_size = 10
+
+[[boards]]
+name = "best-time"
+sort_order = "low_to_high"
+ties = "shared"
+`,
+	}
+	for format, data := range formats {
+		config, err := ParseConfig([]byte(data), format)
+		c.Assert(err, gocheck.IsNil, gocheck.Commentf(format))
+		c.Assert(config.Redis.KeyPrefix, gocheck.Equals, "game:")
+		c.Assert(len(config.Boards), gocheck.Equals, 2)
+		c.Assert(config.Boards[1].SortOrder, gocheck.Equals, "low_to_high")
+		c.Assert(config.Validate(), gocheck.IsNil)
+	}
+	_, err := ParseConfig([]byte("boards:\n  - name: x\n    pagesize: 3\n"), "yaml")
+	c.Assert(err, gocheck.NotNil)
+	_, err = ParseConfig([]byte("[redis]\nhots = \"x\"\n"), "toml")
+	c.Assert(err, gocheck.NotNil)
+	_, err = ParseConfig([]byte("{}"), "ini")
+	c.Assert(err, gocheck.NotNil)
+}
+
+func (s *S) TestConfigValidate(c *gocheck.C) {
+	config := &Config{Boards: []BoardConfig{
+		{Name: "highscore"},
+		{Name: "highscore", PageSize: -1, SortOrder: "sideways"},
+	}}
+	err := config.Validate()
+	c.Assert(err, gocheck.FitsTypeOf, ConfigErrors{})
+	fields := []string{}
+	for _, configErr := range err.(ConfigErrors) {
+		fields = append(fields, configErr.Field)
+	}
+	c.Assert(fields, gocheck.DeepEquals, []string{"redis.host", "boards[1].name", "boards[1].page_size", "boards[1].sort_order"})
+}
+
+func (s *S) TestConfigApplyEnv(c *gocheck.C) {
+	config, _ := ParseConfig([]byte(yamlConfig), "yaml")
+	env := map[string]string{
+		"LEADERBOARD_REDIS_HOST":            "redis:6380",
+		"LEADERBOARD_BEST_TIME_PAGE_SIZE":   "50",
+		"LEADERBOARD_HIGHSCORE_TIES":        "shared",
+		"LEADERBOARD_BEST_TIME_PARENT":      "highscore",
+		"LEADERBOARD_HIGHSCORE_AGGREGATION": "sum",
+	}
+	err := config.ApplyEnv(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(config.Redis.Host, gocheck.Equals, "redis:6380")
+	c.Assert(config.Boards[1].PageSize, gocheck.Equals, 50)
+	c.Assert(config.Boards[0].Ties, gocheck.Equals, "shared")
+	c.Assert(config.Boards[1].Parent, gocheck.Equals, "highscore")
+	c.Assert(config.Boards[0].Aggregation, gocheck.Equals, "sum")
+
+	env["LEADERBOARD_HIGHSCORE_PAGE_SIZE"] = "ten"
+	err = config.ApplyEnv(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+	c.Assert(err, gocheck.ErrorMatches, ".*LEADERBOARD_HIGHSCORE_PAGE_SIZE: must be an integer")
+}
+
+func (s *S) TestLoadConfigRegistry(c *gocheck.C) {
+	path := filepath.Join(c.MkDir(), "leaderboards.yaml")
+	c.Assert(ioutil.WriteFile(path, []byte(yamlConfig), 0644), gocheck.IsNil)
+	config, err := LoadConfig(path)
+	c.Assert(err, gocheck.IsNil)
+	registry, err := config.Registry()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(registry.Boards()), gocheck.Equals, 2)
+	bestTime, ok := registry.Get("best-time")
+	c.Assert(ok, gocheck.Equals, true)
+	c.Assert(bestTime.Order, gocheck.Equals, LowToHigh)
+	c.Assert(bestTime.PageSize, gocheck.Equals, DefaultPageSize)
+	c.Assert(bestTime.key(), gocheck.Equals, "game:best-time")
+	c.Assert(registry.Register(bestTime), gocheck.NotNil)
+	_, ok = registry.Get("unknown")
+	c.Assert(ok, gocheck.Equals, false)
+}
+
+func (s *S) TestConfigHierarchy(c *gocheck.C) {
+	config, err := ParseConfig([]byte(`
+redis:
+  host: localhost:6379
+boards:
+  - name: laps
+    sort_order: low_to_high
+  - name: laps:monaco
+    parent: laps
+    sort_order: low_to_high
+  - name: points
+    aggregation: sum
+    parent: points:season
+`), "yaml")
+	c.Assert(err, gocheck.IsNil)
+	err = config.Validate()
+	c.Assert(err, gocheck.ErrorMatches, `.*boards\[2\]\.parent: "points:season" must be defined before its children`)
+
+	config.Boards = config.Boards[:2]
+	registry, err := config.Registry()
+	c.Assert(err, gocheck.IsNil)
+	parent, _ := registry.Parent("laps:monaco")
+	c.Assert(parent.Name, gocheck.Equals, "laps")
+}
+
+func (s *S) TestConfigWindowsSegmentsAndCap(c *gocheck.C) {
+	formats := map[string]string{
+		"yaml": `
+redis:
+  host: localhost:6379
+boards:
+  - name: configWindows
+    windows: [daily, weekly]
+    segments:
+      brazil:
+        country: [BR]
+    cap: 100
+`,
+		"json": `{"redis": {"host": "localhost:6379"}, "boards": [{"name": "configWindows", "windows": ["daily", "weekly"],
+			"segments": {"brazil": {"country": ["BR"]}}, "cap": 100}]}`,
+		"toml": `
+[redis]
+host = "localhost:6379"
+
+[[boards]]
+name = "configWindows"
+windows = ["daily", "weekly"]
+cap = 100
+
+[boards.segments.brazil]
+country = ["BR"]
+`,
+	}
+	for format, data := range formats {
+		config, err := ParseConfig([]byte(data), format)
+		c.Assert(err, gocheck.IsNil, gocheck.Commentf(format))
+		c.Assert(config.Boards[0].Windows, gocheck.DeepEquals, []string{"daily", "weekly"})
+		c.Assert(config.Boards[0].Segments, gocheck.DeepEquals, map[string]Filter{"brazil": {"country": {"BR"}}})
+		c.Assert(config.Boards[0].Cap, gocheck.Equals, 100)
+
+		registry, err := config.Registry()
+		c.Assert(err, gocheck.IsNil, gocheck.Commentf(format))
+		board, _ := registry.Get("configWindows")
+		c.Assert(board.windows, gocheck.DeepEquals, []Window{WindowDaily, WindowWeekly})
+		c.Assert(board.cap, gocheck.Equals, 100)
+		filter, ok := board.Segment("brazil")
+		c.Assert(ok, gocheck.Equals, true)
+		c.Assert(filter, gocheck.DeepEquals, Filter{"country": {"BR"}})
+	}
+
+	config, _ := ParseConfig([]byte(formats["yaml"]), "yaml")
+	err := config.ApplyEnv(func(name string) (string, bool) {
+		value, ok := map[string]string{"LEADERBOARD_CONFIGWINDOWS_WINDOWS": "monthly, hourly", "LEADERBOARD_CONFIGWINDOWS_CAP": "-1"}[name]
+		return value, ok
+	})
+	c.Assert(err, gocheck.IsNil)
+	config.Boards[0].Segments["brazil"]["platform"] = nil
+	fields := []string{}
+	for _, configErr := range config.Validate().(ConfigErrors) {
+		fields = append(fields, configErr.Field)
+	}
+	c.Assert(fields, gocheck.DeepEquals, []string{"boards[0].windows[1]", "boards[0].segments.brazil.platform", "boards[0].cap"})
+}
@@ -0,0 +1,161 @@
+package leaderboard
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// DailyBoard keeps one leaderboard per calendar day, where every member
+// plays on the day of its own time zone: a score counts towards the day
+// the member was in when it was ranked, and resets at its local midnight.
+// Members without a stored time zone use Location. The days older than
+// Retention days are removed by Cleanup.
+type DailyBoard struct {
+	Leaderboard Leaderboard
+	Location    *time.Location
+	Retention   int
+}
+
+/* End Structs model */
+
+// DefaultDailyRetention is how many past days a daily board keeps when
+// created without a retention.
+const DefaultDailyRetention = 7
+
+const dateLayout = "2006-01-02"
+
+/* Private functions */
+
+func (d *DailyBoard) zonesKey() string {
+	return d.Leaderboard.subKey(":zones")
+}
+
+// daysKey is the sorted set of the days holding a leaderboard, scored by
+// their number of days since the Unix epoch.
+func (d *DailyBoard) daysKey() string {
+	return d.Leaderboard.subKey(":days")
+}
+
+func dayNumber(date time.Time) int64 {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+}
+
+func (d *DailyBoard) zone(conn redis.Conn, member string) (*time.Location, error) {
+	name, err := redis.String(conn.Do("HGET", d.zonesKey(), member))
+	if err == redis.ErrNil {
+		return d.Location, nil
+	}
+	if err != nil {
+		return d.Location, err
+	}
+	return time.LoadLocation(name)
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// NewDailyBoard creates a daily board whose days are stored under the name
+// of board. A nil location defaults to UTC and a retention below one to
+// DefaultDailyRetention.
+func NewDailyBoard(board Leaderboard, location *time.Location, retention int) DailyBoard {
+	if location == nil {
+		location = time.UTC
+	}
+	if retention < 1 {
+		retention = DefaultDailyRetention
+	}
+	return DailyBoard{Leaderboard: board, Location: location, Retention: retention}
+}
+
+// SetMemberZone stores the time zone a member plays in. The location must be
+// loadable by name, i.e. come from time.LoadLocation.
+func (d *DailyBoard) SetMemberZone(member string, location *time.Location) error {
+	_, err := d.Leaderboard.invoke("DailyBoard.SetMemberZone", []interface{}{member, location}, func(args []interface{}) (interface{}, error) {
+		zone := args[1].(*time.Location).String()
+		if _, err := time.LoadLocation(zone); err != nil {
+			return nil, err
+		}
+		conn := d.Leaderboard.conn()
+		defer conn.Close()
+		return conn.Do("HSET", d.zonesKey(), args[0], zone)
+	})
+	return err
+}
+
+// MemberZone returns the time zone of a member, or Location when it has
+// none.
+func (d *DailyBoard) MemberZone(member string) (*time.Location, error) {
+	conn := d.Leaderboard.conn()
+	defer conn.Close()
+	return d.zone(conn, member)
+}
+
+// Day returns the leaderboard of the calendar day holding date, as read on
+// the wall clock of date's own location.
+func (d *DailyBoard) Day(date time.Time) Leaderboard {
+	return d.Leaderboard.derive(":day:" + date.Format(dateLayout))
+}
+
+// RankMember ranks a member on the current day of its time zone and returns
+// its position on that day.
+func (d *DailyBoard) RankMember(member string, score int) (User, error) {
+	conn := d.Leaderboard.conn()
+	defer conn.Close()
+	location, err := d.zone(conn, member)
+	if err != nil {
+		return User{Name: member}, err
+	}
+	today := d.Leaderboard.now().In(location)
+	if _, err := conn.Do("ZADD", d.daysKey(), dayNumber(today), today.Format(dateLayout)); err != nil {
+		return User{Name: member}, err
+	}
+	board := d.Day(today)
+	return board.RankMember(member, score)
+}
+
+// Today returns the leaderboard of the current day of viewer, in its stored
+// time zone.
+func (d *DailyBoard) Today(viewer string) (Leaderboard, error) {
+	location, err := d.MemberZone(viewer)
+	if err != nil {
+		return d.TodayIn(d.Location), err
+	}
+	return d.TodayIn(location), nil
+}
+
+// TodayIn returns the leaderboard of the current day in location.
+func (d *DailyBoard) TodayIn(location *time.Location) Leaderboard {
+	return d.Day(d.Leaderboard.now().In(location))
+}
+
+// Cleanup removes the leaderboards of the days that are more than Retention
+// days behind the current day in every time zone, along with their ghosts,
+// metadata and submission counts, and returns how many were removed.
+func (d *DailyBoard) Cleanup(now time.Time) (int, error) {
+	conn := d.Leaderboard.conn()
+	defer conn.Close()
+	// No time zone is more than a day behind UTC.
+	threshold := "(" + strconv.FormatInt(dayNumber(now.UTC())-1-int64(d.Retention), 10)
+	days, err := redis.Strings(conn.Do("ZRANGEBYSCORE", d.daysKey(), "-inf", threshold))
+	if err != nil || len(days) == 0 {
+		return 0, err
+	}
+	args := redis.Args{}
+	for _, day := range days {
+		board := d.Leaderboard.derive(":day:" + day)
+		args = args.Add(board.key(), board.ghostsKey(), board.metaKey(), board.submissionsKey())
+	}
+	conn.Send("DEL", args...)
+	conn.Send("ZREMRANGEBYSCORE", d.daysKey(), "-inf", threshold)
+	if _, err := doPipeline(conn); err != nil {
+		return 0, err
+	}
+	return len(days), nil
+}
+
+/* End Public functions */
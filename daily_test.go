@@ -0,0 +1,64 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestDailyBoardUsesMemberZone(c *gocheck.C) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+	clock := NewManualClock(time.Date(2013, 5, 1, 20, 0, 0, 0, time.UTC))
+	board, _ := New(redisSettings, "daily", WithPageSize(10), WithClock(clock))
+	daily := NewDailyBoard(board, time.UTC, 2)
+	c.Assert(daily.SetMemberZone("dayvson", saoPaulo), gocheck.IsNil)
+	c.Assert(daily.SetMemberZone("kenji", tokyo), gocheck.IsNil)
+
+	daily.RankMember("dayvson", 50)
+	daily.RankMember("kenji", 70)
+	daily.RankMember("felipe", 30)
+
+	zone, _ := daily.MemberZone("kenji")
+	c.Assert(zone.String(), gocheck.Equals, "Asia/Tokyo")
+	first := daily.Day(time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(first.TotalMembers(), gocheck.Equals, 2)
+	c.Assert(first.GetRank("dayvson"), gocheck.Equals, 1)
+	second, _ := daily.Today("kenji")
+	c.Assert(second.Name, gocheck.Equals, "daily:day:2013-05-02")
+	c.Assert(second.GetRank("kenji"), gocheck.Equals, 1)
+
+	clock.Advance(8 * time.Hour)
+	user, _ := daily.RankMember("dayvson", 10)
+	c.Assert(user.Rank, gocheck.Equals, 2)
+	today, _ := daily.Today("dayvson")
+	c.Assert(today.Name, gocheck.Equals, "daily:day:2013-05-02")
+	utc := daily.TodayIn(time.UTC)
+	c.Assert(utc.TotalMembers(), gocheck.Equals, 2)
+}
+
+func (s *S) TestDailyBoardCleanup(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 12, 0, 0, 0, time.UTC))
+	board, _ := New(redisSettings, "dailyCleanup", WithClock(clock), WithInterceptors(CountSubmissions()))
+	daily := NewDailyBoard(board, nil, 1)
+	for i := 0; i < 4; i++ {
+		daily.RankMember("dayvson", i)
+		clock.Advance(24 * time.Hour)
+	}
+	stale := daily.Day(time.Date(2013, 5, 2, 0, 0, 0, 0, time.UTC))
+	stale.AddGhost("developer", 10)
+	stale.SetMemberData("dayvson", map[string]string{"country": "br"})
+	removed, err := daily.Cleanup(clock.Now())
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(removed, gocheck.Equals, 2)
+	c.Assert(stale.TotalMembers(), gocheck.Equals, 0)
+	conn := board.conn()
+	defer conn.Close()
+	exists, _ := redis.Int(conn.Do("EXISTS", stale.ghostsKey(), stale.metaKey(), stale.submissionsKey()))
+	c.Assert(exists, gocheck.Equals, 0)
+	kept := daily.Day(time.Date(2013, 5, 3, 0, 0, 0, 0, time.UTC))
+	c.Assert(kept.TotalMembers(), gocheck.Equals, 1)
+	removed, _ = daily.Cleanup(clock.Now())
+	c.Assert(removed, gocheck.Equals, 0)
+}
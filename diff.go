@@ -0,0 +1,253 @@
+package leaderboard
+
+import (
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// DiffKind tells how a member differs between two leaderboards.
+type DiffKind int
+
+const (
+	// OnlyInA is a member of the first leaderboard missing from the second.
+	OnlyInA DiffKind = iota
+	// OnlyInB is a member of the second leaderboard missing from the first.
+	OnlyInB
+	// ScoreMismatch is a member scoring differently on the two leaderboards.
+	ScoreMismatch
+	// RankShift is a member with the same score on both leaderboards but
+	// another rank, because of the members around it.
+	RankShift
+)
+
+// Difference is a member differing between two leaderboards. A and B hold the
+// member as ranked on each of them, with a zero Rank where it is missing.
+type Difference struct {
+	Member string
+	Kind   DiffKind
+	A      User
+	B      User
+}
+
+// DiffStats counts the differences of each kind.
+type DiffStats struct {
+	OnlyInA       int
+	OnlyInB       int
+	ScoreMismatch int
+	RankShift     int
+}
+
+/* End Structs model */
+
+// DefaultDiffBatchSize is how many members a diff reads at once when given
+// no batch size.
+const DefaultDiffBatchSize = 500
+
+/* Private functions */
+
+func (s *DiffStats) count(kind DiffKind) {
+	switch kind {
+	case OnlyInA:
+		s.OnlyInA++
+	case OnlyInB:
+		s.OnlyInB++
+	case ScoreMismatch:
+		s.ScoreMismatch++
+	case RankShift:
+		s.RankShift++
+	}
+}
+
+// scanMembers returns a batch of the members of l and the cursor of the next
+// batch, 0 after the last one.
+func (l *Leaderboard) scanMembers(conn redis.Conn, cursor int, count int) (int, []string, error) {
+	values, err := redis.Values(conn.Do("ZSCAN", l.key(), cursor, "COUNT", count))
+	if err != nil {
+		return 0, nil, err
+	}
+	var pairs []string
+	if _, err := redis.Scan(values, &cursor, &pairs); err != nil {
+		return 0, nil, err
+	}
+	members := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		members = append(members, pairs[i])
+	}
+	return cursor, members, nil
+}
+
+// lookupMembers returns members as ranked on l, with whether they were
+// found, in one round trip.
+func (l *Leaderboard) lookupMembers(conn redis.Conn, members []string) ([]User, []bool, error) {
+	for _, member := range members {
+		l.sendMember(conn, member)
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, nil, err
+	}
+	users := make([]User, len(members))
+	found := make([]bool, len(members))
+	for i, member := range members {
+		user, err := receiveMember(conn, member)
+		if err != nil && err != redis.ErrNil {
+			return nil, nil, err
+		}
+		users[i], found[i] = user, err == nil
+	}
+	return users, found, nil
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// Diff walks two leaderboards in batches of batchSize members, which may live
+// on different backends, and calls fn with every difference found. An error
+// from fn stops the diff. Members changing during the diff may be reported
+// twice or not at all, as with SCAN.
+func Diff(a *Leaderboard, b *Leaderboard, batchSize int, fn func(Difference) error) (DiffStats, error) {
+	if batchSize < 1 {
+		batchSize = DefaultDiffBatchSize
+	}
+	connA, connB := a.conn(), b.conn()
+	defer connA.Close()
+	defer connB.Close()
+	stats := DiffStats{}
+	report := func(d Difference) error {
+		stats.count(d.Kind)
+		return fn(d)
+	}
+	cursor := 0
+	for {
+		next, members, err := a.scanMembers(connA, cursor, batchSize)
+		if err != nil {
+			return stats, err
+		}
+		usersA, foundA, err := a.lookupMembers(connA, members)
+		if err != nil {
+			return stats, err
+		}
+		usersB, foundB, err := b.lookupMembers(connB, members)
+		if err != nil {
+			return stats, err
+		}
+		for i, member := range members {
+			d := Difference{Member: member, A: usersA[i], B: usersB[i]}
+			switch {
+			case !foundA[i]:
+				continue
+			case !foundB[i]:
+				d.Kind = OnlyInA
+			case usersA[i].Score != usersB[i].Score:
+				d.Kind = ScoreMismatch
+			case usersA[i].Rank != usersB[i].Rank:
+				d.Kind = RankShift
+			default:
+				continue
+			}
+			if err := report(d); err != nil {
+				return stats, err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	for {
+		next, members, err := b.scanMembers(connB, cursor, batchSize)
+		if err != nil {
+			return stats, err
+		}
+		for _, member := range members {
+			connA.Send("ZSCORE", a.key(), member)
+		}
+		scores, err := redis.Values(connA.Do(""))
+		if err != nil {
+			return stats, err
+		}
+		missing := []string{}
+		for i, score := range scores {
+			if score == nil {
+				missing = append(missing, members[i])
+			}
+		}
+		usersB, foundB, err := b.lookupMembers(connB, missing)
+		if err != nil {
+			return stats, err
+		}
+		for i, member := range missing {
+			if !foundB[i] {
+				continue
+			}
+			if err := report(Difference{Member: member, Kind: OnlyInB, A: User{Name: member}, B: usersB[i]}); err != nil {
+				return stats, err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return stats, nil
+		}
+	}
+}
+
+// Reconcile makes target agree with source for the given kinds of
+// differences, writing in batches of batchSize members: OnlyInA adds the
+// members missing from target, ScoreMismatch copies the scores of source and
+// OnlyInB removes the members missing from source. Without kinds, missing
+// members are added and scores copied. Only the scores are reconciled, not
+// the metadata of members. It returns the differences found and how many
+// members were written to target. The writes start once the diff is done.
+func Reconcile(source *Leaderboard, target *Leaderboard, batchSize int, kinds ...DiffKind) (DiffStats, int, error) {
+	if batchSize < 1 {
+		batchSize = DefaultDiffBatchSize
+	}
+	if len(kinds) == 0 {
+		kinds = []DiffKind{OnlyInA, ScoreMismatch}
+	}
+	apply := map[DiffKind]bool{}
+	for _, kind := range kinds {
+		apply[kind] = true
+	}
+	// Diff first, so the writes do not shift the ranks it still compares.
+	differences := []Difference{}
+	stats, err := Diff(source, target, batchSize, func(d Difference) error {
+		if apply[d.Kind] {
+			differences = append(differences, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, 0, err
+	}
+	conn := target.conn()
+	defer conn.Close()
+	written := 0
+	for start := 0; start < len(differences); start += batchSize {
+		end := start + batchSize
+		if end > len(differences) {
+			end = len(differences)
+		}
+		queued := 0
+		for _, d := range differences[start:end] {
+			switch d.Kind {
+			case OnlyInA, ScoreMismatch:
+				conn.Send("ZADD", target.key(), d.A.Score, d.Member)
+			case OnlyInB:
+				conn.Send("ZREM", target.key(), d.Member)
+			default:
+				continue
+			}
+			queued++
+		}
+		if queued == 0 {
+			continue
+		}
+		if _, err := doPipeline(conn); err != nil {
+			return stats, written, err
+		}
+		written += queued
+	}
+	return stats, written, nil
+}
+
+/* End Public functions */
@@ -0,0 +1,82 @@
+package leaderboard
+
+import (
+	"errors"
+	"strconv"
+
+	"launchpad.net/gocheck"
+)
+
+func newDiffBoards() (Leaderboard, Leaderboard) {
+	a := NewLeaderboard(redisSettings, "diffA", 10)
+	b := NewLeaderboard(redisSettings, "diffB", 10)
+	for i := 0; i < 20; i++ {
+		name := "member_" + strconv.Itoa(i)
+		a.RankMember(name, i*10)
+		b.RankMember(name, i*10)
+	}
+	a.RankMember("restored", 1000)
+	b.RankMember("member_5", 55)
+	b.RankMember("extra", 5)
+	b.RemoveMember("member_0")
+	return a, b
+}
+
+func (s *S) TestDiff(c *gocheck.C) {
+	a, b := newDiffBoards()
+	differences := map[string]Difference{}
+	stats, err := Diff(&a, &b, 4, func(d Difference) error {
+		differences[d.Member] = d
+		return nil
+	})
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(stats.OnlyInA, gocheck.Equals, 2)
+	c.Assert(stats.OnlyInB, gocheck.Equals, 1)
+	c.Assert(stats.ScoreMismatch, gocheck.Equals, 1)
+	c.Assert(differences["restored"].Kind, gocheck.Equals, OnlyInA)
+	c.Assert(differences["restored"].A.Rank, gocheck.Equals, 1)
+	c.Assert(differences["extra"].Kind, gocheck.Equals, OnlyInB)
+	c.Assert(differences["extra"].B.Score, gocheck.Equals, 5)
+	c.Assert(differences["member_5"].Kind, gocheck.Equals, ScoreMismatch)
+	c.Assert(differences["member_5"].B.Score, gocheck.Equals, 55)
+	c.Assert(differences["member_19"].Kind, gocheck.Equals, RankShift)
+	c.Assert(differences["member_19"].A.Rank, gocheck.Equals, 2)
+	c.Assert(differences["member_19"].B.Rank, gocheck.Equals, 1)
+
+	failure := errors.New("stop")
+	_, err = Diff(&a, &b, 4, func(d Difference) error { return failure })
+	c.Assert(err, gocheck.Equals, failure)
+}
+
+func (s *S) TestReconcile(c *gocheck.C) {
+	a, b := newDiffBoards()
+	stats, written, err := Reconcile(&a, &b, 2)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(stats.OnlyInA, gocheck.Equals, 2)
+	c.Assert(written, gocheck.Equals, 3)
+	user, _ := b.GetMember("member_5")
+	c.Assert(user.Score, gocheck.Equals, 50)
+	c.Assert(b.GetRank("extra"), gocheck.Equals, 21)
+
+	_, written, err = Reconcile(&a, &b, 2, OnlyInB)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(written, gocheck.Equals, 1)
+	stats, _ = Diff(&a, &b, 0, func(d Difference) error { return nil })
+	c.Assert(stats, gocheck.Equals, DiffStats{})
+}
+
+func (s *S) TestReconcileWritesAfterDiff(c *gocheck.C) {
+	a := NewLeaderboard(redisSettings, "diffA", 10)
+	b := NewLeaderboard(redisSettings, "diffB", 10)
+	for i := 0; i < 300; i++ {
+		name := "member_" + strconv.Itoa(i)
+		a.RankMember(name, i)
+		b.RankMember(name, i+i%2*1000)
+	}
+	expected, err := Diff(&a, &b, 1, func(d Difference) error { return nil })
+	c.Assert(err, gocheck.IsNil)
+	stats, written, err := Reconcile(&a, &b, 1)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(stats, gocheck.Equals, expected)
+	c.Assert(written, gocheck.Equals, 150)
+}
@@ -0,0 +1,324 @@
+package leaderboard
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Entries ranks several entries per account, e.g. the characters or
+// loadouts of a player. Entries are the members of Leaderboard, and the best
+// entry of every account is also kept on a leaderboard of accounts.
+type Entries struct {
+	Leaderboard Leaderboard
+}
+
+// Entry is an entry with the account owning it.
+type Entry struct {
+	User
+	Account string
+}
+
+/* End Structs model */
+
+// entriesLua defines best(account), storing the best entry of an account
+// on the leaderboard of accounts or removing the account when it has none,
+// and drop(entry), forgetting the account of an entry. The entries of an
+// account are the members "account\0entry" of a sorted set of equal scores.
+// KEYS: board, entry accounts, account entries, best board, best entries.
+const entriesLua = `
+local function best(account, order)
+	local bestEntry, bestScore
+	local owned = redis.call('ZRANGEBYLEX', KEYS[3], '[' .. account .. '\0', '(' .. account .. '\1')
+	for _, member in ipairs(owned) do
+		local entry = string.sub(member, #account + 2)
+		local score = tonumber(redis.call('ZSCORE', KEYS[1], entry))
+		if score and (not bestScore
+			or (order == '1' and score < bestScore)
+			or (order ~= '1' and score > bestScore)
+			or (score == bestScore and entry < bestEntry)) then
+			bestEntry, bestScore = entry, score
+		end
+	end
+	if bestEntry then
+		redis.call('ZADD', KEYS[4], bestScore, account)
+		redis.call('HSET', KEYS[5], account, bestEntry)
+	else
+		redis.call('ZREM', KEYS[4], account)
+		redis.call('HDEL', KEYS[5], account)
+	end
+end
+
+local function drop(entry, order)
+	local account = redis.call('HGET', KEYS[2], entry)
+	if account then
+		redis.call('HDEL', KEYS[2], entry)
+		redis.call('ZREM', KEYS[3], account .. '\0' .. entry)
+		best(account, order)
+	end
+end
+`
+
+// rankEntryScript ranks an entry and moves it to account if another account
+// owned it.
+// ARGV: account, entry, score, sort order.
+var rankEntryScript = redis.NewScript(5, entriesLua+`
+local previous = redis.call('HGET', KEYS[2], ARGV[2])
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[2])
+if previous and previous ~= ARGV[1] then
+	drop(ARGV[2], ARGV[4])
+end
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[1])
+redis.call('ZADD', KEYS[3], 0, ARGV[1] .. '\0' .. ARGV[2])
+best(ARGV[1], ARGV[4])
+`)
+
+// rescoreEntryScript updates the best entry of the account owning a member
+// ranked by RankMember.
+// ARGV: member, sort order.
+var rescoreEntryScript = redis.NewScript(5, entriesLua+`
+local account = redis.call('HGET', KEYS[2], ARGV[1])
+if account then
+	best(account, ARGV[2])
+end
+`)
+
+// dropEntryScript forgets the account of a member removed by RemoveMember.
+// ARGV: member, sort order.
+var dropEntryScript = redis.NewScript(5, entriesLua+`
+drop(ARGV[1], ARGV[2])
+`)
+
+// removeEntryScript removes an entry and returns 1 when it was ranked.
+// KEYS: board, entry accounts, account entries, best board, best entries,
+// metadata.
+// ARGV: entry, sort order.
+var removeEntryScript = redis.NewScript(6, entriesLua+`
+local removed = redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[6], ARGV[1])
+drop(ARGV[1], ARGV[2])
+return removed
+`)
+
+// removeAccountScript removes every entry of an account and returns how many
+// were removed.
+// KEYS: board, entry accounts, account entries, best board, best entries,
+// metadata.
+// ARGV: account.
+var removeAccountScript = redis.NewScript(6, `
+local owned = redis.call('ZRANGEBYLEX', KEYS[3], '[' .. ARGV[1] .. '\0', '(' .. ARGV[1] .. '\1')
+for _, member in ipairs(owned) do
+	local entry = string.sub(member, #ARGV[1] + 2)
+	redis.call('ZREM', KEYS[1], entry)
+	redis.call('HDEL', KEYS[6], entry)
+	redis.call('HDEL', KEYS[2], entry)
+	redis.call('ZREM', KEYS[3], member)
+end
+redis.call('ZREM', KEYS[4], ARGV[1])
+redis.call('HDEL', KEYS[5], ARGV[1])
+return #owned
+`)
+
+/* Private functions */
+
+func (l *Leaderboard) accountsKey() string {
+	return l.subKey(":accounts")
+}
+
+func (l *Leaderboard) accountEntriesKey() string {
+	return l.subKey(":account:entries")
+}
+
+func (l *Leaderboard) bestEntriesKey() string {
+	return l.subKey(":best:entries")
+}
+
+// entryKeys returns the keys shared by the entry scripts.
+func (l *Leaderboard) entryKeys() []interface{} {
+	best := l.derive(":best")
+	return []interface{}{l.key(), l.accountsKey(), l.accountEntriesKey(), best.key(), l.bestEntriesKey()}
+}
+
+// sendEntryScore queues the update of the best entry of the account owning
+// member, when the leaderboard keeps entries. It returns how many replies to
+// read back.
+func (l *Leaderboard) sendEntryScore(conn redis.Conn, member string) int {
+	if !l.entries {
+		return 0
+	}
+	rescoreEntryScript.Send(conn, append(l.entryKeys(), member, int(l.Order))...)
+	return 1
+}
+
+// sendEntryRemoval queues forgetting the account of member, when the
+// leaderboard keeps entries. It returns how many replies to read back.
+func (l *Leaderboard) sendEntryRemoval(conn redis.Conn, member string) int {
+	if !l.entries {
+		return 0
+	}
+	dropEntryScript.Send(conn, append(l.entryKeys(), member, int(l.Order))...)
+	return 1
+}
+
+// withAccounts pairs users with the accounts owning them, read from the hash
+// at key. Users without a name are left without an account.
+func withAccounts(conn redis.Conn, key string, users []User) ([]Entry, error) {
+	entries := make([]Entry, len(users))
+	args := redis.Args{}.Add(key)
+	for i, user := range users {
+		entries[i].User = user
+		if user.Name != "" {
+			args = args.Add(user.Name)
+		}
+	}
+	if len(args) == 1 {
+		return entries, nil
+	}
+	accounts, err := redis.Strings(conn.Do("HMGET", args...))
+	if err != nil {
+		return entries, err
+	}
+	j := 0
+	for i := range entries {
+		if entries[i].Name != "" {
+			entries[i].Account = accounts[j]
+			j++
+		}
+	}
+	return entries, nil
+}
+
+func (e *Entries) rankEntry(account string, entry string, score int) (Entry, error) {
+	conn := e.Leaderboard.conn()
+	defer conn.Close()
+	args := append(e.Leaderboard.entryKeys(), account, entry, score, int(e.Leaderboard.Order))
+	if _, err := rankEntryScript.Do(conn, args...); err != nil {
+		return Entry{User: User{Name: entry}, Account: account}, err
+	}
+	user, err := e.Leaderboard.getMember(conn, entry)
+	return Entry{User: user, Account: account}, err
+}
+
+func (e *Entries) removeEntry(entry string) (bool, error) {
+	conn := e.Leaderboard.conn()
+	defer conn.Close()
+	args := append(e.Leaderboard.entryKeys(), e.Leaderboard.metaKey(), entry, int(e.Leaderboard.Order))
+	removed, err := redis.Int(removeEntryScript.Do(conn, args...))
+	return removed == 1, err
+}
+
+func (e *Entries) removeAccount(account string) (int, error) {
+	conn := e.Leaderboard.conn()
+	defer conn.Close()
+	args := append(e.Leaderboard.entryKeys(), e.Leaderboard.metaKey(), account)
+	return redis.Int(removeAccountScript.Do(conn, args...))
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// NewEntries ranks entries on board. Build board WithEntries for its own
+// RankMember, RemoveMember and cap to keep the accounts in step too.
+func NewEntries(board Leaderboard) Entries {
+	board.entries = true
+	return Entries{Leaderboard: board}
+}
+
+// Best is the leaderboard of accounts, ranked by the score of their best
+// entry.
+func (e *Entries) Best() Leaderboard {
+	return e.Leaderboard.derive(":best")
+}
+
+// RankEntry ranks an entry of account and returns its rank among all the
+// entries.
+func (e *Entries) RankEntry(account string, entry string, score int) (Entry, error) {
+	result, err := e.Leaderboard.invoke("Entries.RankEntry", []interface{}{account, entry, score}, func(args []interface{}) (interface{}, error) {
+		return e.rankEntry(args[0].(string), args[1].(string), args[2].(int))
+	})
+	ranked, _ := result.(Entry)
+	return ranked, err
+}
+
+// Account returns the account owning an entry, or redis.ErrNil.
+func (e *Entries) Account(entry string) (string, error) {
+	conn := e.Leaderboard.conn()
+	defer conn.Close()
+	return redis.String(conn.Do("HGET", e.Leaderboard.accountsKey(), entry))
+}
+
+// AccountEntries returns the entries of an account in rank order.
+func (e *Entries) AccountEntries(account string) ([]Entry, error) {
+	conn := e.Leaderboard.conn()
+	defer conn.Close()
+	owned, err := redis.Strings(conn.Do("ZRANGEBYLEX", e.Leaderboard.accountEntriesKey(), "["+account+"\x00", "("+account+"\x01"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(owned))
+	for i, member := range owned {
+		names[i] = strings.TrimPrefix(member, account+"\x00")
+	}
+	for _, name := range names {
+		e.Leaderboard.sendMember(conn, name)
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, name := range names {
+		user, err := receiveMember(conn, name)
+		if err == nil {
+			entries = append(entries, Entry{User: user, Account: account})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Rank < entries[j].Rank })
+	return entries, nil
+}
+
+// GetLeaders returns a page of all the entries with their accounts.
+func (e *Entries) GetLeaders(page int) ([]Entry, error) {
+	users := e.Leaderboard.GetLeaders(page)
+	conn := e.Leaderboard.conn()
+	defer conn.Close()
+	return withAccounts(conn, e.Leaderboard.accountsKey(), users)
+}
+
+// GetBestLeaders returns a page of the best entry of every account. Ranks
+// are the ranks of the accounts.
+func (e *Entries) GetBestLeaders(page int) ([]Entry, error) {
+	best := e.Best()
+	users := best.GetLeaders(page)
+	conn := e.Leaderboard.conn()
+	defer conn.Close()
+	accounts, err := withAccounts(conn, e.Leaderboard.bestEntriesKey(), users)
+	for i := range accounts {
+		accounts[i].Name, accounts[i].Account = accounts[i].Account, accounts[i].Name
+	}
+	return accounts, err
+}
+
+// RemoveEntry removes an entry and returns whether it was ranked.
+func (e *Entries) RemoveEntry(entry string) (bool, error) {
+	result, err := e.Leaderboard.invoke("Entries.RemoveEntry", []interface{}{entry}, func(args []interface{}) (interface{}, error) {
+		return e.removeEntry(args[0].(string))
+	})
+	removed, _ := result.(bool)
+	return removed, err
+}
+
+// RemoveAccount removes an account with all its entries and returns how many
+// entries were removed.
+func (e *Entries) RemoveAccount(account string) (int, error) {
+	result, err := e.Leaderboard.invoke("Entries.RemoveAccount", []interface{}{account}, func(args []interface{}) (interface{}, error) {
+		return e.removeAccount(args[0].(string))
+	})
+	removed, _ := result.(int)
+	return removed, err
+}
+
+/* End Public functions */
@@ -0,0 +1,107 @@
+package leaderboard
+
+import (
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestRankEntries(c *gocheck.C) {
+	entries := NewEntries(NewLeaderboard(redisSettings, "entries", 10))
+	entries.RankEntry("dayvson", "warrior", 30)
+	entries.RankEntry("dayvson", "mage", 50)
+	entries.RankEntry("felipe", "rogue", 40)
+	entry, err := entries.RankEntry("felipe", "priest", 20)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(entry.Rank, gocheck.Equals, 4)
+	c.Assert(entry.Account, gocheck.Equals, "felipe")
+
+	leaders, _ := entries.GetLeaders(1)
+	c.Assert(leaders[0].Name, gocheck.Equals, "mage")
+	c.Assert(leaders[0].Account, gocheck.Equals, "dayvson")
+	c.Assert(leaders[1].Account, gocheck.Equals, "felipe")
+	c.Assert(leaders[4].Account, gocheck.Equals, "")
+
+	best, _ := entries.GetBestLeaders(1)
+	c.Assert(best[0].Name, gocheck.Equals, "mage")
+	c.Assert(best[0].Account, gocheck.Equals, "dayvson")
+	c.Assert(best[1].Name, gocheck.Equals, "rogue")
+	c.Assert(best[1].Rank, gocheck.Equals, 2)
+	c.Assert(best[2].Name, gocheck.Equals, "")
+
+	own, _ := entries.AccountEntries("dayvson")
+	c.Assert(len(own), gocheck.Equals, 2)
+	c.Assert(own[0].Name, gocheck.Equals, "mage")
+	c.Assert(own[1].Rank, gocheck.Equals, 3)
+
+	entries.RankEntry("felipe", "mage", 10)
+	account, _ := entries.Account("mage")
+	c.Assert(account, gocheck.Equals, "felipe")
+	accounts := entries.Best()
+	c.Assert(accounts.GetRank("felipe"), gocheck.Equals, 1)
+	c.Assert(accounts.GetRank("dayvson"), gocheck.Equals, 2)
+}
+
+func (s *S) TestRemoveEntries(c *gocheck.C) {
+	entries := NewEntries(NewLeaderboard(redisSettings, "removedEntries", 10))
+	entries.RankEntry("dayvson", "warrior", 30)
+	entries.RankEntry("dayvson", "mage", 50)
+	entries.RankEntry("felipe", "rogue", 40)
+	entries.Leaderboard.SetMemberData("mage", map[string]string{"class": "mage"})
+
+	removed, err := entries.RemoveEntry("mage")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(removed, gocheck.Equals, true)
+	best, _ := entries.GetBestLeaders(1)
+	c.Assert(best[0].Name, gocheck.Equals, "rogue")
+	c.Assert(best[1].Name, gocheck.Equals, "warrior")
+	data, _ := entries.Leaderboard.GetMemberData("mage")
+	c.Assert(len(data), gocheck.Equals, 0)
+
+	n, err := entries.RemoveAccount("dayvson")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(n, gocheck.Equals, 1)
+	c.Assert(entries.Leaderboard.TotalMembers(), gocheck.Equals, 1)
+	accounts := entries.Best()
+	c.Assert(accounts.TotalMembers(), gocheck.Equals, 1)
+	_, err = entries.Account("warrior")
+	c.Assert(err, gocheck.Equals, redis.ErrNil)
+}
+
+func (s *S) TestEntriesFollowMemberWrites(c *gocheck.C) {
+	board, _ := New(redisSettings, "memberEntries", WithEntries())
+	entries := NewEntries(board)
+	entries.RankEntry("dayvson", "warrior", 30)
+	entries.RankEntry("dayvson", "mage", 50)
+	entries.RankEntry("felipe", "rogue", 40)
+
+	board.RankMember("warrior", 60)
+	best, _ := entries.GetBestLeaders(1)
+	c.Assert(best[0].Name, gocheck.Equals, "warrior")
+	c.Assert(best[0].Score, gocheck.Equals, 60)
+
+	board.RemoveMember("warrior")
+	_, err := entries.Account("warrior")
+	c.Assert(err, gocheck.Equals, redis.ErrNil)
+	own, _ := entries.AccountEntries("dayvson")
+	c.Assert(len(own), gocheck.Equals, 1)
+	best, _ = entries.GetBestLeaders(1)
+	c.Assert(best[0].Name, gocheck.Equals, "mage")
+
+	board.RemoveMember("rogue")
+	accounts := entries.Best()
+	c.Assert(accounts.TotalMembers(), gocheck.Equals, 1)
+}
+
+func (s *S) TestEntriesTrimmedByCap(c *gocheck.C) {
+	board, _ := New(redisSettings, "cappedEntries", WithCap(2))
+	entries := NewEntries(board)
+	entries.RankEntry("dayvson", "warrior", 30)
+	entries.RankEntry("felipe", "rogue", 40)
+	entries.Leaderboard.RankMember("mage", 50)
+
+	_, err := entries.Account("warrior")
+	c.Assert(err, gocheck.Equals, redis.ErrNil)
+	accounts := entries.Best()
+	c.Assert(accounts.GetRank("dayvson"), gocheck.Equals, 0)
+	c.Assert(accounts.GetRank("felipe"), gocheck.Equals, 1)
+}
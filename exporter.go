@@ -0,0 +1,330 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/* Structs model */
+
+// BoardStats is a sample of the statistics of a leaderboard. Top is the first
+// placed player and Quantiles maps a quantile, e.g. 0.9, to the score below or
+// at which that share of the players stands, whatever the sort order. Ghosts
+// only count in Members.
+type BoardStats struct {
+	Members     int
+	Players     int
+	Submissions int64
+	Top         User
+	Quantiles   map[float64]int
+}
+
+// Exporter is a Prometheus collector of the statistics of the leaderboards of
+// a registry. Run samples them every Interval by Clock; a scrape reports the
+// last sample, so scrapes never hit Redis.
+type Exporter struct {
+	Registry  *Registry
+	Interval  time.Duration
+	Quantiles []float64
+	Logger    Logger
+	Clock     Clock
+
+	mu      sync.Mutex
+	samples []boardSample
+}
+
+// boardSample is the outcome of sampling a leaderboard.
+type boardSample struct {
+	board string
+	stats BoardStats
+	err   error
+}
+
+/* End Structs model */
+
+// DefaultQuantiles are the score quantiles exported when none are given.
+var DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+
+// quantilesScript returns the names and scores of the players at offsets
+// counted from the lowest score, skipping ghosts, or nils past the last
+// player.
+// KEYS: board, ghosts.
+// ARGV: offsets.
+var quantilesScript = redis.NewScript(2, `
+local ghosts = {}
+for _, ghost in ipairs(redis.call('ZRANGE', KEYS[2], 0, -1)) do
+	local position = redis.call('ZRANK', KEYS[1], ghost)
+	if position then
+		table.insert(ghosts, position)
+	end
+end
+table.sort(ghosts)
+local result = {}
+for i = 1, #ARGV do
+	local position = tonumber(ARGV[i])
+	for _, ghost in ipairs(ghosts) do
+		if ghost <= position then
+			position = position + 1
+		end
+	end
+	local member = redis.call('ZRANGE', KEYS[1], position, position, 'WITHSCORES')
+	result[2 * i - 1] = member[1] or false
+	result[2 * i] = member[2] or false
+end
+return result
+`)
+
+var (
+	upDesc          = prometheus.NewDesc("leaderboard_up", "Whether the last sample of the leaderboard succeeded.", []string{"board"}, nil)
+	membersDesc     = prometheus.NewDesc("leaderboard_members", "Number of members ranked on the leaderboard.", []string{"board"}, nil)
+	playersDesc     = prometheus.NewDesc("leaderboard_players", "Number of members of the leaderboard that are not ghosts.", []string{"board"}, nil)
+	submissionsDesc = prometheus.NewDesc("leaderboard_submissions_total", "Number of scores submitted to the leaderboard, counted by CountSubmissions.", []string{"board"}, nil)
+	topScoreDesc    = prometheus.NewDesc("leaderboard_top_score", "Score of the first placed player of the leaderboard.", []string{"board"}, nil)
+	scoreDesc       = prometheus.NewDesc("leaderboard_score", "Score quantiles of the players of the leaderboard.", []string{"board", "quantile"}, nil)
+)
+
+// submitOperations lists the intercepted operations submitting a score.
+var submitOperations = map[string]bool{
+	"RankMember":             true,
+	"Batch.RankMember":       true,
+	"Entries.RankEntry":      true,
+	"Moderation.Submit":      true,
+	"ReplicatedBoard.Submit": true,
+}
+
+/* Private functions */
+
+func (l *Leaderboard) submissionsKey() string {
+	return l.subKey(":submissions")
+}
+
+// quantileOffset returns the offset, from the lowest score, of the member
+// holding quantile q of n scores by the nearest rank method.
+func quantileOffset(q float64, n int) int {
+	offset := int(math.Ceil(q*float64(n))) - 1
+	if offset < 0 {
+		return 0
+	}
+	if offset > n-1 {
+		return n - 1
+	}
+	return offset
+}
+
+func checkQuantiles(quantiles []float64) error {
+	for _, q := range quantiles {
+		if q < 0 || q > 1 || math.IsNaN(q) {
+			return fmt.Errorf("leaderboard: quantile must be between 0 and 1, got %v", q)
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) logf(format string, v ...interface{}) {
+	if e.Logger != nil {
+		e.Logger.Printf(format, v...)
+	}
+}
+
+func (e *Exporter) clock() Clock {
+	if e.Clock != nil {
+		return e.Clock
+	}
+	return SystemClock
+}
+
+func (e *Exporter) lastSamples() []boardSample {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.samples
+}
+
+func (l *Leaderboard) stats(quantiles []float64) (BoardStats, error) {
+	stats := BoardStats{Quantiles: map[float64]int{}}
+	if err := checkQuantiles(quantiles); err != nil {
+		return stats, err
+	}
+	conn := l.conn()
+	defer conn.Close()
+	conn.Send("ZCARD", l.key())
+	conn.Send("ZCARD", l.ghostsKey())
+	conn.Send("GET", l.submissionsKey())
+	values, err := doPipeline(conn)
+	if err != nil {
+		return stats, err
+	}
+	if len(values) != 3 {
+		return stats, fmt.Errorf("leaderboard: unexpected stats reply of %d values", len(values))
+	}
+	if stats.Members, err = redis.Int(values[0], nil); err != nil {
+		return stats, err
+	}
+	ghosts, err := redis.Int(values[1], nil)
+	if err != nil {
+		return stats, err
+	}
+	stats.Players = stats.Members - ghosts
+	if stats.Submissions, err = redis.Int64(values[2], nil); err != nil && err != redis.ErrNil {
+		return stats, err
+	}
+	if stats.Players <= 0 {
+		return stats, nil
+	}
+	// The first placed player is looked up along with the quantiles, at the
+	// offset of the highest or the lowest score.
+	top := 0
+	if l.Order == HighToLow {
+		top = stats.Players - 1
+	}
+	args := redis.Args{}.Add(l.key(), l.ghostsKey(), top)
+	for _, q := range quantiles {
+		args = args.Add(quantileOffset(q, stats.Players))
+	}
+	if values, err = redis.Values(quantilesScript.Do(conn, args...)); err != nil {
+		return stats, err
+	}
+	if len(values) != 2*(len(quantiles)+1) {
+		return stats, fmt.Errorf("leaderboard: unexpected quantiles reply of %d values", len(values))
+	}
+	if values[0] != nil {
+		name, err := redis.String(values[0], nil)
+		if err != nil {
+			return stats, err
+		}
+		if stats.Top, err = l.getMember(conn, name); err != nil {
+			return stats, err
+		}
+	}
+	for i, q := range quantiles {
+		if score := values[2*i+3]; score != nil {
+			if stats.Quantiles[q], err = redis.Int(score, nil); err != nil {
+				return stats, err
+			}
+		}
+	}
+	return stats, nil
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// CountSubmissions returns an interceptor counting the successful score
+// submissions of a leaderboard in Redis, exported as its submission total:
+// RankMember, Batch.RankMember when queued, Entries.RankEntry,
+// Moderation.Submit and ReplicatedBoard.Submit. Every process ranking members
+// must use it for the total to be complete.
+func CountSubmissions() Interceptor {
+	return func(inv *Invocation, next Handler) (interface{}, error) {
+		result, err := next(inv)
+		if submitOperations[inv.Operation] && err == nil {
+			conn := inv.Leaderboard.conn()
+			if _, err := conn.Do("INCR", inv.Leaderboard.submissionsKey()); err != nil {
+				inv.Leaderboard.logf("error on count submission Leaderboard:%s", inv.Leaderboard.Name)
+			}
+			conn.Close()
+		}
+		return result, err
+	}
+}
+
+// Stats samples the size, the submission total, the first placed player and
+// the score quantiles of the players of the leaderboard. Quantiles must be
+// between 0 and 1.
+func (l *Leaderboard) Stats(quantiles ...float64) (BoardStats, error) {
+	result, err := l.invoke("Stats", []interface{}{quantiles}, func(args []interface{}) (interface{}, error) {
+		return l.stats(args[0].([]float64))
+	})
+	stats, _ := result.(BoardStats)
+	return stats, err
+}
+
+// NewExporter returns an exporter of the leaderboards of registry sampled
+// every interval. It exports DefaultQuantiles when no quantile is given.
+func NewExporter(registry *Registry, interval time.Duration, quantiles ...float64) (*Exporter, error) {
+	if interval < time.Millisecond {
+		return nil, fmt.Errorf("leaderboard: exporter interval must be at least a millisecond, got %s", interval)
+	}
+	if err := checkQuantiles(quantiles); err != nil {
+		return nil, err
+	}
+	if len(quantiles) == 0 {
+		quantiles = DefaultQuantiles
+	}
+	return &Exporter{Registry: registry, Interval: interval, Quantiles: quantiles}, nil
+}
+
+// Sample takes the statistics of every registered leaderboard, reported by
+// the following scrapes.
+func (e *Exporter) Sample() {
+	samples := []boardSample{}
+	for _, board := range e.Registry.Boards() {
+		stats, err := board.Stats(e.Quantiles...)
+		if err != nil {
+			e.logf("error on sample stats Leaderboard:%s - %s", board.Name, err)
+		}
+		samples = append(samples, boardSample{board: board.Name, stats: stats, err: err})
+	}
+	e.mu.Lock()
+	e.samples = samples
+	e.mu.Unlock()
+}
+
+// Run samples the leaderboards every Interval until ctx is done.
+func (e *Exporter) Run(ctx context.Context) {
+	clock := e.clock()
+	e.Sample()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(e.Interval):
+		}
+		e.Sample()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{upDesc, membersDesc, playersDesc, submissionsDesc, topScoreDesc, scoreDesc} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector. It samples the leaderboards first
+// when Run has not yet.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	samples := e.lastSamples()
+	if samples == nil {
+		e.Sample()
+		samples = e.lastSamples()
+	}
+	for _, sample := range samples {
+		if sample.err != nil {
+			ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, sample.board)
+			continue
+		}
+		stats := sample.stats
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, sample.board)
+		ch <- prometheus.MustNewConstMetric(membersDesc, prometheus.GaugeValue, float64(stats.Members), sample.board)
+		ch <- prometheus.MustNewConstMetric(playersDesc, prometheus.GaugeValue, float64(stats.Players), sample.board)
+		ch <- prometheus.MustNewConstMetric(submissionsDesc, prometheus.CounterValue, float64(stats.Submissions), sample.board)
+		if stats.Players <= 0 {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(topScoreDesc, prometheus.GaugeValue, float64(stats.Top.Score), sample.board)
+		for _, q := range e.Quantiles {
+			quantile := strconv.FormatFloat(q, 'g', -1, 64)
+			ch <- prometheus.MustNewConstMetric(scoreDesc, prometheus.GaugeValue, float64(stats.Quantiles[q]), sample.board, quantile)
+		}
+	}
+}
+
+/* End Public functions */
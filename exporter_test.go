@@ -0,0 +1,127 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestStats(c *gocheck.C) {
+	lapTime, _ := New(redisSettings, "statsLaps", WithSortOrder(LowToHigh), WithInterceptors(CountSubmissions()))
+	for i := 1; i <= 100; i++ {
+		lapTime.RankMember("member_"+strconv.Itoa(i), 1000+i)
+	}
+	lapTime.RankMember("member_1", 900)
+	lapTime.AddGhost("developer", 800)
+
+	stats, err := lapTime.Stats(0, 0.5, 0.9, 1)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(stats.Members, gocheck.Equals, 101)
+	c.Assert(stats.Players, gocheck.Equals, 100)
+	c.Assert(stats.Submissions, gocheck.Equals, int64(101))
+	c.Assert(stats.Top.Name, gocheck.Equals, "member_1")
+	c.Assert(stats.Top.Score, gocheck.Equals, 900)
+	c.Assert(stats.Top.Ghost, gocheck.Equals, false)
+	c.Assert(stats.Quantiles, gocheck.DeepEquals, map[float64]int{0: 900, 0.5: 1050, 0.9: 1090, 1: 1100})
+
+	empty := NewLeaderboard(redisSettings, "statsEmpty", 10)
+	stats, err = empty.Stats(0.5)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(stats.Members, gocheck.Equals, 0)
+	c.Assert(stats.Quantiles, gocheck.DeepEquals, map[float64]int{})
+	_, err = empty.Stats(1.5)
+	c.Assert(err, gocheck.ErrorMatches, "leaderboard: quantile must be between 0 and 1, got 1.5")
+}
+
+func (s *S) TestStatsTopBehindGhosts(c *gocheck.C) {
+	kills, _ := New(redisSettings, "statsGhosts", WithGhostPolicy(GhostsUnranked))
+	kills.RankMember("dayvson", 10)
+	kills.RankMember("felipe", 30)
+	kills.AddGhost("developer", 50)
+	stats, err := kills.Stats(1)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(stats.Top, gocheck.DeepEquals, User{Name: "felipe", Score: 30, Rank: 1})
+	c.Assert(stats.Quantiles, gocheck.DeepEquals, map[float64]int{1: 30})
+
+	conn := kills.conn()
+	conn.Do("HSET", kills.submissionsKey(), "total", 1)
+	conn.Close()
+	_, err = kills.Stats()
+	c.Assert(err, gocheck.ErrorMatches, "WRONGTYPE.*")
+}
+
+func (s *S) TestExporter(c *gocheck.C) {
+	registry := NewRegistry()
+	kills := NewLeaderboard(redisSettings, "statsKills", 10)
+	kills.RankMember("dayvson", 10)
+	kills.RankMember("felipe", 30)
+	registry.Register(kills)
+	registry.Register(NewLeaderboard(redisSettings, "statsEmpty", 10))
+
+	_, err := NewExporter(registry, 0)
+	c.Assert(err, gocheck.NotNil)
+	_, err = NewExporter(registry, time.Second, -0.1)
+	c.Assert(err, gocheck.NotNil)
+	exporter, err := NewExporter(registry, time.Second, 0.5)
+	c.Assert(err, gocheck.IsNil)
+
+	expected := `
+# HELP leaderboard_members Number of members ranked on the leaderboard.
+# TYPE leaderboard_members gauge
+leaderboard_members{board="statsEmpty"} 0
+leaderboard_members{board="statsKills"} 2
+# HELP leaderboard_score Score quantiles of the players of the leaderboard.
+# TYPE leaderboard_score gauge
+leaderboard_score{board="statsKills",quantile="0.5"} 10
+# HELP leaderboard_top_score Score of the first placed player of the leaderboard.
+# TYPE leaderboard_top_score gauge
+leaderboard_top_score{board="statsKills"} 30
+# HELP leaderboard_up Whether the last sample of the leaderboard succeeded.
+# TYPE leaderboard_up gauge
+leaderboard_up{board="statsEmpty"} 1
+leaderboard_up{board="statsKills"} 1
+`
+	names := []string{"leaderboard_members", "leaderboard_score", "leaderboard_top_score", "leaderboard_up"}
+	c.Assert(testutil.CollectAndCompare(exporter, strings.NewReader(expected), names...), gocheck.IsNil)
+
+	kills.RankMember("arthur", 50)
+	c.Assert(testutil.CollectAndCompare(exporter, strings.NewReader(expected), names...), gocheck.IsNil)
+	exporter.Sample()
+	sampled := strings.NewReplacer(`"statsKills"} 2`, `"statsKills"} 3`, `"0.5"} 10`, `"0.5"} 30`, `"statsKills"} 30`, `"statsKills"} 50`).Replace(expected)
+	c.Assert(testutil.CollectAndCompare(exporter, strings.NewReader(sampled), names...), gocheck.IsNil)
+}
+
+func (s *S) TestExporterRunsOnClock(c *gocheck.C) {
+	registry := NewRegistry()
+	runs := NewLeaderboard(redisSettings, "statsRuns", 10)
+	registry.Register(runs)
+	exporter, err := NewExporter(registry, time.Minute)
+	c.Assert(err, gocheck.IsNil)
+	clock := NewManualClock(time.Date(2013, 5, 1, 12, 0, 0, 0, time.UTC))
+	exporter.Clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go exporter.Run(ctx)
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	runs.RankMember("dayvson", 10)
+	clock.Advance(59 * time.Second)
+	members := `
+# HELP leaderboard_members Number of members ranked on the leaderboard.
+# TYPE leaderboard_members gauge
+leaderboard_members{board="statsRuns"} %d
+`
+	c.Assert(testutil.CollectAndCompare(exporter, strings.NewReader(fmt.Sprintf(members, 0)), "leaderboard_members"), gocheck.IsNil)
+	clock.Advance(time.Second)
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(testutil.CollectAndCompare(exporter, strings.NewReader(fmt.Sprintf(members, 1)), "leaderboard_members"), gocheck.IsNil)
+}
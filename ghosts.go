@@ -0,0 +1,226 @@
+package leaderboard
+
+import (
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// GhostPolicy tells how a leaderboard shows ghosts, the entries that are not
+// players such as developer times seeding a new leaderboard.
+type GhostPolicy int
+
+const (
+	// NoGhosts does not look ghosts up. It is the default policy.
+	NoGhosts GhostPolicy = iota
+	// GhostsRanked marks ghosts and ranks them like players.
+	GhostsRanked
+	// GhostsUnranked marks ghosts, gives them a zero Rank and leaves them out
+	// of the ranks of players.
+	GhostsUnranked
+)
+
+/* End Structs model */
+
+// ghostsLua defines ghostsBefore(rank, score, order, ties), counting the
+// ghosts placed before a member of the given rank, counted from zero, and
+// score. The ghosts key is a sorted set holding the score of every ghost, so
+// only the ghosts sharing the score of the member are looked up one by one.
+// KEYS: board, ghosts.
+const ghostsLua = `
+local function ghostsBefore(rank, score, order, ties)
+	local count
+	if order == '1' then
+		count = redis.call('ZCOUNT', KEYS[2], '-inf', '(' .. score)
+	else
+		count = redis.call('ZCOUNT', KEYS[2], '(' .. score, '+inf')
+	end
+	if ties ~= '1' then
+		for _, ghost in ipairs(redis.call('ZRANGEBYSCORE', KEYS[2], score, score)) do
+			local position
+			if order == '1' then
+				position = redis.call('ZRANK', KEYS[1], ghost)
+			else
+				position = redis.call('ZREVRANK', KEYS[1], ghost)
+			end
+			if position and position < rank then
+				count = count + 1
+			end
+		end
+	end
+	return count
+end
+`
+
+/* Private functions */
+
+// ghostsKey is the sorted set holding the ghosts of the leaderboard with
+// their scores.
+func (l *Leaderboard) ghostsKey() string {
+	return l.subKey(":ghosts")
+}
+
+// markGhosts flags the ghosts among users, which hold ranks counting every
+// member, and leaves ghosts out of the ranks when they are unranked, like
+// ghostsBefore does for a single member.
+func (l *Leaderboard) markGhosts(source rankSource, users []User) error {
+	if l.ghosts == NoGhosts || len(users) == 0 {
+		return nil
+	}
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Name
+	}
+	ghosts, err := source.holds(l.ghostsKey(), names)
+	if err != nil {
+		return err
+	}
+	players := []User{}
+	for i := range users {
+		users[i].Ghost = ghosts[i]
+		if ghosts[i] && l.ghosts == GhostsUnranked {
+			users[i].Rank = 0
+		} else if !ghosts[i] {
+			players = append(players, users[i])
+		}
+	}
+	if l.ghosts != GhostsUnranked || len(players) == 0 {
+		return nil
+	}
+	scores := distinctScores(players)
+	better, err := source.countBefore(l.ghostsKey(), scores)
+	if err != nil {
+		return err
+	}
+	before := map[int]int{}
+	for i, score := range scores {
+		before[score] = better[i]
+	}
+	// Ghosts tied with a player come before it when it is ranked by member.
+	tied := map[int][]int{}
+	if l.ties != TieShared {
+		holding, err := source.holding(l.ghostsKey(), scores)
+		if err != nil {
+			return err
+		}
+		for i, score := range scores {
+			if len(holding[i]) == 0 {
+				continue
+			}
+			if tied[score], err = source.positions(holding[i]); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range users {
+		if users[i].Ghost {
+			continue
+		}
+		count := before[users[i].Score]
+		for _, position := range tied[users[i].Score] {
+			if position >= 0 && position < users[i].Rank-1 {
+				count++
+			}
+		}
+		users[i].Rank -= count
+	}
+	return nil
+}
+
+func (l *Leaderboard) addGhost(name string, score int) (User, error) {
+	conn := l.conn()
+	defer conn.Close()
+	conn.Send("ZADD", l.ghostsKey(), score, name)
+	if _, err := conn.Do("ZADD", l.key(), score, name); err != nil {
+		return User{Name: name, Score: score, Ghost: true}, err
+	}
+	user, err := l.getMember(conn, name)
+	user.Ghost = true
+	return user, err
+}
+
+func (l *Leaderboard) isGhost(member string) (bool, error) {
+	conn := l.conn()
+	defer conn.Close()
+	score, err := conn.Do("ZSCORE", l.ghostsKey(), member)
+	return score != nil, err
+}
+
+func (l *Leaderboard) totalPlayers() int {
+	conn := l.conn()
+	defer conn.Close()
+	conn.Send("ZCARD", l.key())
+	conn.Send("ZCARD", l.ghostsKey())
+	values, err := redis.Ints(doPipeline(conn))
+	if err != nil || len(values) != 2 {
+		l.logf("error on get leaderboard total players")
+		return 0
+	}
+	return values[0] - values[1]
+}
+
+func (l *Leaderboard) topPlayers(n int) []User {
+	if n < 1 {
+		return []User{}
+	}
+	conn := l.conn()
+	ghosts, _ := redis.Int(conn.Do("ZCARD", l.ghostsKey()))
+	conn.Close()
+	size := n + ghosts
+	marked := *l
+	if marked.ghosts == NoGhosts {
+		marked.ghosts = GhostsRanked
+	}
+	players := []User{}
+	for _, user := range marked.getMembersByRange(size, 0, size-1, false) {
+		if user.Name != "" && !user.Ghost && len(players) < n {
+			players = append(players, user)
+		}
+	}
+	return players
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// AddGhost ranks a ghost. Ghosts are removed with RemoveMember; RankMember
+// and Batch.RankMember keep the score of a ghost, the other writes leave it a
+// ghost of its former score.
+func (l *Leaderboard) AddGhost(name string, score int) (User, error) {
+	result, err := l.invoke("AddGhost", []interface{}{name, score}, func(args []interface{}) (interface{}, error) {
+		return l.addGhost(args[0].(string), args[1].(int))
+	})
+	user, _ := result.(User)
+	return user, err
+}
+
+// IsGhost tells whether member is a ghost.
+func (l *Leaderboard) IsGhost(member string) (bool, error) {
+	result, err := l.invoke("IsGhost", []interface{}{member}, func(args []interface{}) (interface{}, error) {
+		return l.isGhost(args[0].(string))
+	})
+	ghost, _ := result.(bool)
+	return ghost, err
+}
+
+// TotalPlayers returns the number of members that are not ghosts.
+func (l *Leaderboard) TotalPlayers() int {
+	result, _ := l.invoke("TotalPlayers", nil, func(args []interface{}) (interface{}, error) {
+		return l.totalPlayers(), nil
+	})
+	total, _ := result.(int)
+	return total
+}
+
+// TopPlayers returns up to n of the first placed members that are not
+// ghosts, e.g. to hand out rewards.
+func (l *Leaderboard) TopPlayers(n int) []User {
+	result, _ := l.invoke("TopPlayers", []interface{}{n}, func(args []interface{}) (interface{}, error) {
+		return l.topPlayers(args[0].(int)), nil
+	})
+	players, _ := result.([]User)
+	return players
+}
+
+/* End Public functions */
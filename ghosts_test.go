@@ -0,0 +1,105 @@
+package leaderboard
+
+import (
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestGhostsRanked(c *gocheck.C) {
+	board, _ := New(redisSettings, "ghosts", WithPageSize(5), WithGhostPolicy(GhostsRanked))
+	ghost, err := board.AddGhost("developer", 100)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(ghost.Ghost, gocheck.Equals, true)
+	c.Assert(ghost.Rank, gocheck.Equals, 1)
+	board.RankMember("dayvson", 80)
+	board.RankMember("felipe", 120)
+
+	leaders := board.GetLeaders(1)
+	c.Assert(leaders[0].Ghost, gocheck.Equals, false)
+	c.Assert(leaders[1].Name, gocheck.Equals, "developer")
+	c.Assert(leaders[1].Ghost, gocheck.Equals, true)
+	c.Assert(leaders[1].Rank, gocheck.Equals, 2)
+	c.Assert(board.GetRank("dayvson"), gocheck.Equals, 3)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 3)
+	c.Assert(board.TotalPlayers(), gocheck.Equals, 2)
+
+	players := board.TopPlayers(5)
+	c.Assert(len(players), gocheck.Equals, 2)
+	c.Assert(players[1].Name, gocheck.Equals, "dayvson")
+
+	board.RemoveMember("developer")
+	isGhost, _ := board.IsGhost("developer")
+	c.Assert(isGhost, gocheck.Equals, false)
+	c.Assert(board.TotalPlayers(), gocheck.Equals, 2)
+}
+
+func (s *S) TestGhostsUnranked(c *gocheck.C) {
+	board, _ := New(redisSettings, "unrankedGhosts", WithPageSize(5), WithGhostPolicy(GhostsUnranked))
+	board.AddGhost("developer", 100)
+	board.AddGhost("tester", 90)
+	board.RankMember("dayvson", 80)
+	board.RankMember("felipe", 120)
+	board.RankMember("arthur", 95)
+
+	leaders := board.GetLeaders(1)
+	c.Assert(leaders[0].Rank, gocheck.Equals, 1)
+	c.Assert(leaders[1].Name, gocheck.Equals, "developer")
+	c.Assert(leaders[1].Rank, gocheck.Equals, 0)
+	c.Assert(leaders[2].Name, gocheck.Equals, "arthur")
+	c.Assert(leaders[2].Rank, gocheck.Equals, 2)
+	c.Assert(leaders[4].Rank, gocheck.Equals, 3)
+
+	user, _ := board.GetMember("dayvson")
+	c.Assert(user.Rank, gocheck.Equals, 3)
+	c.Assert(board.GetRank("tester"), gocheck.Equals, 0)
+	bottom := board.Bottom(1)
+	c.Assert(bottom[0].Rank, gocheck.Equals, 3)
+
+	plain := NewLeaderboard(redisSettings, "unrankedGhosts", 5)
+	c.Assert(plain.GetRank("dayvson"), gocheck.Equals, 5)
+	c.Assert(plain.GetLeaders(1)[1].Ghost, gocheck.Equals, false)
+}
+
+func (s *S) TestGhostsUnrankedSharedTies(c *gocheck.C) {
+	board, _ := New(redisSettings, "sharedGhosts", WithPageSize(5), WithTiePolicy(TieShared), WithGhostPolicy(GhostsUnranked))
+	board.AddGhost("developer", 100)
+	board.RankMember("dayvson", 100)
+	board.RankMember("felipe", 100)
+	board.RankMember("arthur", 90)
+
+	c.Assert(board.GetRank("felipe"), gocheck.Equals, 1)
+	c.Assert(board.GetRank("arthur"), gocheck.Equals, 3)
+	leaders := board.GetLeaders(1)
+	c.Assert(leaders[3].Name, gocheck.Equals, "arthur")
+	c.Assert(leaders[3].Rank, gocheck.Equals, 3)
+}
+
+func (s *S) TestGhostsTiedByMember(c *gocheck.C) {
+	board, _ := New(redisSettings, "tiedGhosts", WithPageSize(5), WithGhostPolicy(GhostsUnranked))
+	board.AddGhost("bob", 100)
+	board.RankMember("alice", 100)
+	board.RankMember("carol", 100)
+	board.RankMember("dave", 50)
+
+	c.Assert(board.GetRank("carol"), gocheck.Equals, 1)
+	c.Assert(board.GetRank("alice"), gocheck.Equals, 2)
+	c.Assert(board.GetRank("dave"), gocheck.Equals, 3)
+
+	board.RankMember("bob", 40)
+	isGhost, _ := board.IsGhost("bob")
+	c.Assert(isGhost, gocheck.Equals, true)
+	c.Assert(board.GetRank("alice"), gocheck.Equals, 2)
+	c.Assert(board.GetRank("dave"), gocheck.Equals, 3)
+}
+
+func (s *S) TestGhostsTrimmedByCap(c *gocheck.C) {
+	board, _ := New(redisSettings, "cappedGhosts", WithCap(2), WithGhostPolicy(GhostsRanked))
+	board.AddGhost("developer", 10)
+	board.AddGhost("tester", 20)
+	board.RankMember("dayvson", 30)
+	board.RankMember("felipe", 40)
+
+	c.Assert(board.TotalMembers(), gocheck.Equals, 2)
+	c.Assert(board.TotalPlayers(), gocheck.Equals, 2)
+	isGhost, _ := board.IsGhost("tester")
+	c.Assert(isGhost, gocheck.Equals, false)
+}
@@ -0,0 +1,181 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/* Structs model */
+
+// goRedisBackend hands out connections running their commands through a
+// go-redis client.
+type goRedisBackend struct {
+	client goredis.UniversalClient
+}
+
+// goRedisConn adapts a go-redis client to redis.Conn. Sent commands are
+// queued on a go-redis pipeline executed on Flush.
+type goRedisConn struct {
+	client  goredis.UniversalClient
+	pipe    goredis.Pipeliner
+	sent    []*goredis.Cmd
+	flushed []*goredis.Cmd
+}
+
+/* End Structs model */
+
+// ErrGoRedisProtocol is returned by NewGoRedisBackend for a client not using
+// the RESP2 protocol. go-redis defaults to RESP3, which changes the shape of
+// replies such as ZRANGE WITHSCORES.
+var ErrGoRedisProtocol = errors.New("leaderboard: go-redis client must use Protocol: 2")
+
+/* Private functions */
+
+func (c *goRedisConn) command(cmd string, args []interface{}) []interface{} {
+	return append([]interface{}{cmd}, args...)
+}
+
+func (c *goRedisConn) Close() error {
+	c.pipe, c.sent, c.flushed = nil, nil, nil
+	return nil
+}
+
+func (c *goRedisConn) Err() error {
+	return nil
+}
+
+func (c *goRedisConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	if cmd == "" {
+		replies := make([]interface{}, len(c.flushed))
+		for i := range replies {
+			reply, err := c.Receive()
+			if e, ok := err.(redis.Error); ok {
+				reply = e
+			} else if err != nil {
+				return nil, err
+			}
+			replies[i] = reply
+		}
+		return replies, nil
+	}
+	var err error
+	for len(c.flushed) > 0 {
+		if _, e := c.Receive(); err == nil {
+			err = e
+		}
+	}
+	reply, e := convertGoRedisReply(c.client.Do(context.Background(), c.command(cmd, args)...).Result())
+	if e != nil {
+		err = e
+	}
+	return reply, err
+}
+
+func (c *goRedisConn) Send(cmd string, args ...interface{}) error {
+	if c.pipe == nil {
+		c.pipe = c.client.Pipeline()
+	}
+	c.sent = append(c.sent, c.pipe.Do(context.Background(), c.command(cmd, args)...))
+	return nil
+}
+
+func (c *goRedisConn) Flush() error {
+	if c.pipe == nil {
+		return nil
+	}
+	pipe := c.pipe
+	c.pipe = nil
+	c.flushed = append(c.flushed, c.sent...)
+	c.sent = nil
+	_, err := pipe.Exec(context.Background())
+	var redisErr goredis.Error
+	if err != nil && err != goredis.Nil && !errors.As(err, &redisErr) {
+		c.flushed = nil
+		return err
+	}
+	return nil
+}
+
+func (c *goRedisConn) Receive() (interface{}, error) {
+	if len(c.flushed) == 0 {
+		return nil, errors.New("leaderboard: receive without a pending reply")
+	}
+	cmd := c.flushed[0]
+	c.flushed = c.flushed[1:]
+	return convertGoRedisReply(cmd.Result())
+}
+
+func (b goRedisBackend) Get() redis.Conn {
+	return &goRedisConn{client: b.client}
+}
+
+// convertGoRedisReply turns a go-redis reply into the shape redigo gives it:
+// bulk strings as []byte, nil replies without error and error replies as
+// redis.Error.
+func convertGoRedisReply(reply interface{}, err error) (interface{}, error) {
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	var redisErr goredis.Error
+	if errors.As(err, &redisErr) {
+		return nil, redis.Error(redisErr.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch reply := reply.(type) {
+	case string:
+		return []byte(reply), nil
+	case float64:
+		return []byte(strconv.FormatFloat(reply, 'f', -1, 64)), nil
+	case []interface{}:
+		values := make([]interface{}, len(reply))
+		for i, value := range reply {
+			values[i], err = convertGoRedisReply(value, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case error:
+		return convertGoRedisReply(nil, reply)
+	}
+	return reply, nil
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// NewGoRedisBackend runs the commands of a leaderboard through a go-redis
+// client, sharing its connection pool and hooks. Pass it to WithBackend. The
+// client must use the RESP2 protocol (Protocol: 2 in its options), which is
+// the reply shape the leaderboard reads; ErrGoRedisProtocol is returned
+// otherwise. Cluster clients are supported: the keys a script of a
+// leaderboard touches share the hash tag of its key. Leaderboards combined in
+// one script, the boards of a Registry hierarchy, need a common hash tag,
+// e.g. WithKeyPrefix("{game}:").
+func NewGoRedisBackend(client goredis.UniversalClient) (Backend, error) {
+	protocol := 2
+	switch client := client.(type) {
+	case *goredis.Client:
+		protocol = client.Options().Protocol
+	case *goredis.ClusterClient:
+		protocol = client.Options().Protocol
+	case *goredis.Ring:
+		protocol = client.Options().Protocol
+	}
+	if protocol != 2 {
+		return nil, ErrGoRedisProtocol
+	}
+	return goRedisBackend{client: client}, nil
+}
+
+/* End Public functions */
@@ -0,0 +1,257 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/garyburd/redigo/redis"
+	goredis "github.com/redis/go-redis/v9"
+	"launchpad.net/gocheck"
+)
+
+func newGoRedisBoard(c *gocheck.C, name string, options ...Option) (Leaderboard, *goredis.Client) {
+	client := goredis.NewClient(&goredis.Options{Addr: redisSettings.Host, Protocol: 2})
+	backend, err := NewGoRedisBackend(client)
+	c.Assert(err, gocheck.IsNil)
+	options = append(options, WithBackend(backend))
+	board, err := New(RedisSettings{}, name, options...)
+	c.Assert(err, gocheck.IsNil)
+	return board, client
+}
+
+func (s *S) TestGoRedisBackend(c *gocheck.C) {
+	board, client := newGoRedisBoard(c, "goRedis", WithPageSize(5), WithTiePolicy(TieShared))
+	defer client.Close()
+	for i := 0; i < 12; i++ {
+		_, err := board.RankMember("member_"+strconv.Itoa(i), 100*i)
+		c.Assert(err, gocheck.IsNil)
+	}
+	board.RankMember("member_12", 1100)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 13)
+	c.Assert(board.TotalPages(), gocheck.Equals, 3)
+
+	member, err := board.GetMember("member_11")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(member.Rank, gocheck.Equals, 1)
+	c.Assert(board.GetRank("member_12"), gocheck.Equals, 1)
+	c.Assert(board.GetRank("member_10"), gocheck.Equals, 3)
+	_, err = board.GetMember("unknown")
+	c.Assert(err, gocheck.Equals, redis.ErrNil)
+
+	users := board.GetLeaders(2)
+	c.Assert(users[0].Name, gocheck.Equals, "member_7")
+	c.Assert(users[0].Rank, gocheck.Equals, 6)
+	users = board.Bottom(2)
+	c.Assert(users[0].Name, gocheck.Equals, "member_0")
+	c.Assert(users[0].Rank, gocheck.Equals, 13)
+
+	comparisons, err := CompareMembers([]Leaderboard{board}, "member_0", "member_11", "unknown")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(comparisons[0].RankGaps[1], gocheck.Equals, -12)
+	c.Assert(comparisons[0].Members[2].Rank, gocheck.Equals, 0)
+
+	board.RemoveMember("member_0")
+	c.Assert(board.TotalMembers(), gocheck.Equals, 12)
+}
+
+func (s *S) TestGoRedisConnErrors(c *gocheck.C) {
+	client := goredis.NewClient(&goredis.Options{Addr: redisSettings.Host, Protocol: 2})
+	defer client.Close()
+	backend, err := NewGoRedisBackend(client)
+	c.Assert(err, gocheck.IsNil)
+	conn := backend.Get()
+	defer conn.Close()
+	conn.Do("SET", "goRedisString", "value")
+	_, err = conn.Do("ZADD", "goRedisString", 1, "member")
+	c.Assert(err, gocheck.FitsTypeOf, redis.Error(""))
+
+	conn.Send("GET", "goRedisString")
+	conn.Send("ZCARD", "goRedisString")
+	conn.Send("GET", "goRedisMissing")
+	c.Assert(conn.Flush(), gocheck.IsNil)
+	value, err := redis.String(conn.Receive())
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(value, gocheck.Equals, "value")
+	_, err = conn.Receive()
+	c.Assert(err, gocheck.FitsTypeOf, redis.Error(""))
+	_, err = redis.String(conn.Receive())
+	c.Assert(err, gocheck.Equals, redis.ErrNil)
+}
+
+func (s *S) TestGoRedisStreakBoard(c *gocheck.C) {
+	client := goredis.NewClient(&goredis.Options{Addr: redisSettings.Host, Protocol: 2})
+	defer client.Close()
+	daily := NewStreakBoard(RedisSettings{}, "goRedisStreak", 10, 0, nil)
+	backend, err := NewGoRedisBackend(client)
+	c.Assert(err, gocheck.IsNil)
+	daily.Backend = backend
+	day := time.Date(2013, 5, 1, 10, 0, 0, 0, time.UTC)
+	daily.RecordActivity("dayvson", day)
+	streak, err := daily.RecordActivity("dayvson", day.Add(24*time.Hour))
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(streak.Current, gocheck.Equals, 2)
+	best := daily.Best()
+	c.Assert(best.GetRank("dayvson"), gocheck.Equals, 1)
+	removed, err := daily.BreakStale(day.Add(5 * 24 * time.Hour))
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(removed, gocheck.Equals, 1)
+}
+
+func (s *S) TestGoRedisBackendRejectsRESP3(c *gocheck.C) {
+	client := goredis.NewClient(&goredis.Options{Addr: redisSettings.Host})
+	defer client.Close()
+	_, err := NewGoRedisBackend(client)
+	c.Assert(err, gocheck.Equals, ErrGoRedisProtocol)
+	cluster := goredis.NewClusterClient(&goredis.ClusterOptions{Addrs: []string{redisSettings.Host}, Protocol: 3})
+	defer cluster.Close()
+	_, err = NewGoRedisBackend(cluster)
+	c.Assert(err, gocheck.Equals, ErrGoRedisProtocol)
+}
+
+// keySlot returns the Redis Cluster slot of key.
+func keySlot(key string) int {
+	if open := strings.IndexByte(key, '{'); open >= 0 {
+		if end := strings.IndexByte(key[open+1:], '}'); end > 0 {
+			key = key[open+1 : open+1+end]
+		}
+	}
+	crc := uint16(0)
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return int(crc) % 16384
+}
+
+// commandKeys returns the keys of the multi-key commands sent by the
+// leaderboards.
+func commandKeys(args []interface{}) []string {
+	names := func(values []interface{}) []string {
+		keys := make([]string, len(values))
+		for i, value := range values {
+			keys[i] = fmt.Sprint(value)
+		}
+		return keys
+	}
+	switch strings.ToUpper(fmt.Sprint(args[0])) {
+	case "EVAL", "EVALSHA":
+		n, _ := strconv.Atoi(fmt.Sprint(args[2]))
+		return names(args[3 : 3+n])
+	case "ZUNIONSTORE", "ZINTERSTORE":
+		n, _ := strconv.Atoi(fmt.Sprint(args[2]))
+		return names(append(args[1:2:2], args[3:3+n]...))
+	case "DEL", "UNLINK", "EXISTS":
+		return names(args[1:])
+	case "RENAME":
+		return names(args[1:3])
+	}
+	return nil
+}
+
+// crossSlotHook fails the commands whose keys hash to different slots, as a
+// Redis Cluster of several nodes does.
+type crossSlotHook struct{}
+
+func (crossSlotHook) check(cmd goredis.Cmder) error {
+	keys := commandKeys(cmd.Args())
+	for _, key := range keys {
+		if keySlot(key) != keySlot(keys[0]) {
+			err := fmt.Errorf("CROSSSLOT %s: %v", cmd.Name(), keys)
+			cmd.SetErr(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (crossSlotHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+func (h crossSlotHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		if err := h.check(cmd); err != nil {
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h crossSlotHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		for _, cmd := range cmds {
+			if err := h.check(cmd); err != nil {
+				return err
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
+
+func (s *S) TestGoRedisCluster(c *gocheck.C) {
+	server, err := miniredis.Run()
+	c.Assert(err, gocheck.IsNil)
+	s.servers = append(s.servers, server)
+	client := goredis.NewClusterClient(&goredis.ClusterOptions{Addrs: []string{server.Addr()}, Protocol: 2})
+	defer client.Close()
+	client.AddHook(crossSlotHook{})
+	backend, err := NewGoRedisBackend(client)
+	c.Assert(err, gocheck.IsNil)
+	clock := NewManualClock(time.Date(2013, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	board, err := New(RedisSettings{}, "cluster", WithBackend(backend), WithClock(clock), WithGhostPolicy(GhostsUnranked),
+		WithWindows(WindowDaily), WithCap(10))
+	c.Assert(err, gocheck.IsNil)
+	_, err = board.RankMember("dayvson", 50)
+	c.Assert(err, gocheck.IsNil)
+	_, err = board.AddGhost("record", 90)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(board.SetMemberData("dayvson", map[string]string{"country": "BR"}), gocheck.IsNil)
+	user, err := board.GetMember("dayvson")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Rank, gocheck.Equals, 1)
+	filtered, err := board.FilterRange(Filter{"country": {"BR"}}, 0, 10)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(filtered, gocheck.HasLen, 1)
+	_, err = board.Stats(0.5)
+	c.Assert(err, gocheck.IsNil)
+	history := NewRankHistory(board, time.Hour)
+	c.Assert(history.Sample(time.Time{}, "dayvson"), gocheck.IsNil)
+
+	moderation := NewModeration(board, 1)
+	_, held, err := moderation.Submit("felipe", 100, "video")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(held, gocheck.Equals, true)
+	_, err = moderation.Approve("felipe")
+	c.Assert(err, gocheck.IsNil)
+	entries := NewEntries(board)
+	_, err = entries.RankEntry("acme", "arthur", 70)
+	c.Assert(err, gocheck.IsNil)
+	daily := NewDailyBoard(board, time.UTC, 1)
+	_, err = daily.RankMember("dayvson", 10)
+	c.Assert(err, gocheck.IsNil)
+	_, err = daily.Cleanup(clock.Now().AddDate(0, 0, 5))
+	c.Assert(err, gocheck.IsNil)
+	streaks := NewStreakBoard(RedisSettings{}, "clusterStreak", 10, 0, nil, WithBackend(backend))
+	_, err = streaks.RecordActivity("dayvson", clock.Now())
+	c.Assert(err, gocheck.IsNil)
+
+	registry := NewRegistry()
+	game, _ := New(RedisSettings{}, "game", WithBackend(backend), WithKeyPrefix("{game}:"))
+	race, _ := New(RedisSettings{}, "race", WithBackend(backend), WithKeyPrefix("{game}:"))
+	c.Assert(registry.Register(game), gocheck.IsNil)
+	c.Assert(registry.RegisterChild("game", race), gocheck.IsNil)
+	users, err := registry.RankMember("race", "dayvson", 30)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(users[1].Score, gocheck.Equals, 30)
+}
@@ -0,0 +1,163 @@
+package leaderboard
+
+import (
+	"fmt"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Aggregation tells how a parent leaderboard combines the scores of a member
+// on its children.
+type Aggregation int
+
+const (
+	// AggregateBest keeps the best score of the member on the children, by
+	// the sort order of the parent. It is the default aggregation.
+	AggregateBest Aggregation = iota
+	// AggregateSum adds up the scores of the member on the children.
+	AggregateSum
+)
+
+/* End Structs model */
+
+// aggregateScript recomputes the score of a member on a parent leaderboard
+// from its children, removing the member when no child ranks it. It returns
+// the new score, or nil.
+// KEYS: parent, then every child.
+// ARGV: member, aggregation, sort order of the parent.
+var aggregateScript = redis.NewScript(-1, `
+local result
+for i = 2, #KEYS do
+	local score = tonumber(redis.call('ZSCORE', KEYS[i], ARGV[1]))
+	if score then
+		if not result then
+			result = score
+		elseif ARGV[2] == '1' then
+			result = result + score
+		elseif ARGV[3] == '1' then
+			result = math.min(result, score)
+		else
+			result = math.max(result, score)
+		end
+	end
+end
+if result then
+	redis.call('ZADD', KEYS[1], result, ARGV[1])
+else
+	redis.call('ZREM', KEYS[1], ARGV[1])
+end
+return result
+`)
+
+/* Private functions */
+
+// aggregate recomputes the score of member on the parent leaderboard name
+// from its children and returns it as ranked there.
+func (r *Registry) aggregate(name string, member string) (User, error) {
+	parent := r.boards[name]
+	args := redis.Args{}.Add(len(r.children[name])+1, parent.key())
+	for _, childName := range r.children[name] {
+		child := r.boards[childName]
+		args = args.Add(child.key())
+	}
+	args = args.Add(member, int(parent.aggregate), int(parent.Order))
+	conn := parent.conn()
+	defer conn.Close()
+	if _, err := aggregateScript.Do(conn, args...); err != nil {
+		return User{Name: member}, err
+	}
+	return parent.getMember(conn, member)
+}
+
+// propagate recomputes the score of member on every ancestor of the
+// leaderboard name and returns it as ranked on each, from the parent up.
+func (r *Registry) propagate(name string, member string) ([]User, error) {
+	users := []User{}
+	for parentName, ok := r.parents[name]; ok; parentName, ok = r.parents[parentName] {
+		user, err := r.aggregate(parentName, member)
+		if err != nil && err != redis.ErrNil {
+			return users, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// RegisterChild adds a leaderboard as a child of the registered leaderboard
+// parent. The parent and its children must share a backend and, on Redis
+// Cluster, a hash tag, e.g. WithKeyPrefix("{game}:").
+//
+// Ancestors are only updated by the writes of the registry: RankMember and
+// RemoveMember of Registry, and MergeMembers. Scores written on a child by
+// any other means, e.g. its own RankMember, a Batch or an Entries, do not
+// reach its ancestors.
+func (r *Registry) RegisterChild(parent string, child Leaderboard) error {
+	if _, ok := r.boards[parent]; !ok {
+		return fmt.Errorf("leaderboard: parent %q is not registered", parent)
+	}
+	if err := r.Register(child); err != nil {
+		return err
+	}
+	r.parents[child.Name] = parent
+	r.children[parent] = append(r.children[parent], child.Name)
+	return nil
+}
+
+// Parent returns the parent of the leaderboard name, if it has one.
+func (r *Registry) Parent(name string) (Leaderboard, bool) {
+	parent, ok := r.parents[name]
+	if !ok {
+		return Leaderboard{}, false
+	}
+	return r.boards[parent], true
+}
+
+// Children returns the children of the leaderboard name in registration
+// order.
+func (r *Registry) Children(name string) []Leaderboard {
+	children := make([]Leaderboard, len(r.children[name]))
+	for i, child := range r.children[name] {
+		children[i] = r.boards[child]
+	}
+	return children
+}
+
+// RankMember ranks a member on the leaderboard name and on its ancestors,
+// each combining the scores of its children by its aggregation. It returns
+// the member as ranked on the leaderboard and then on every ancestor, from
+// the parent up. Leaves of a hierarchy must be written through it to keep
+// their ancestors up to date.
+func (r *Registry) RankMember(name string, member string, score int) ([]User, error) {
+	l, ok := r.boards[name]
+	if !ok {
+		return nil, fmt.Errorf("leaderboard: %q is not registered", name)
+	}
+	user, err := l.RankMember(member, score)
+	if err != nil {
+		return []User{user}, err
+	}
+	ancestors, err := r.propagate(name, member)
+	return append([]User{user}, ancestors...), err
+}
+
+// RemoveMember removes a member from the leaderboard name and updates its
+// ancestors, which keep the member while another child ranks it.
+func (r *Registry) RemoveMember(name string, member string) error {
+	l, ok := r.boards[name]
+	if !ok {
+		return fmt.Errorf("leaderboard: %q is not registered", name)
+	}
+	if _, err := l.RemoveMember(member); err != nil && err != redis.ErrNil {
+		return err
+	}
+	_, err := r.propagate(name, member)
+	return err
+}
+
+/* End Public functions */
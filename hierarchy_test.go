@@ -0,0 +1,67 @@
+package leaderboard
+
+import (
+	"launchpad.net/gocheck"
+)
+
+func newHierarchy(c *gocheck.C) *Registry {
+	registry := NewRegistry()
+	game, _ := New(redisSettings, "game", WithAggregation(AggregateSum))
+	c.Assert(registry.Register(game), gocheck.IsNil)
+	for _, mode := range []string{"game:race", "game:drift"} {
+		board, _ := New(redisSettings, mode)
+		c.Assert(registry.RegisterChild("game", board), gocheck.IsNil)
+	}
+	for _, track := range []string{"game:race:desert", "game:race:city"} {
+		board, _ := New(redisSettings, track)
+		c.Assert(registry.RegisterChild("game:race", board), gocheck.IsNil)
+	}
+	board, _ := New(redisSettings, "game:drift:docks")
+	c.Assert(registry.RegisterChild("game:drift", board), gocheck.IsNil)
+	return registry
+}
+
+func (s *S) TestHierarchyPropagates(c *gocheck.C) {
+	registry := newHierarchy(c)
+	users, err := registry.RankMember("game:race:desert", "dayvson", 30)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(users), gocheck.Equals, 3)
+	registry.RankMember("game:race:city", "dayvson", 50)
+	registry.RankMember("game:drift:docks", "dayvson", 20)
+	registry.RankMember("game:race:city", "felipe", 60)
+	users, _ = registry.RankMember("game:race:desert", "dayvson", 40)
+	c.Assert(users[0].Score, gocheck.Equals, 40)
+	c.Assert(users[1].Score, gocheck.Equals, 50)
+	c.Assert(users[1].Rank, gocheck.Equals, 2)
+	c.Assert(users[2].Score, gocheck.Equals, 70)
+	c.Assert(users[2].Rank, gocheck.Equals, 1)
+
+	c.Assert(registry.RemoveMember("game:race:city", "dayvson"), gocheck.IsNil)
+	race, _ := registry.Get("game:race")
+	user, _ := race.GetMember("dayvson")
+	c.Assert(user.Score, gocheck.Equals, 40)
+	game, _ := registry.Get("game")
+	user, _ = game.GetMember("dayvson")
+	c.Assert(user.Score, gocheck.Equals, 60)
+	c.Assert(registry.RemoveMember("game:race:city", "felipe"), gocheck.IsNil)
+	c.Assert(game.GetRank("felipe"), gocheck.Equals, 0)
+
+	_, err = registry.RankMember("unknown", "dayvson", 1)
+	c.Assert(err, gocheck.NotNil)
+}
+
+func (s *S) TestHierarchyNavigation(c *gocheck.C) {
+	registry := newHierarchy(c)
+	parent, ok := registry.Parent("game:race:city")
+	c.Assert(ok, gocheck.Equals, true)
+	c.Assert(parent.Name, gocheck.Equals, "game:race")
+	_, ok = registry.Parent("game")
+	c.Assert(ok, gocheck.Equals, false)
+	children := registry.Children("game")
+	c.Assert(len(children), gocheck.Equals, 2)
+	c.Assert(children[1].Name, gocheck.Equals, "game:drift")
+	c.Assert(len(registry.Children("game:drift:docks")), gocheck.Equals, 0)
+
+	err := registry.RegisterChild("missing", NewLeaderboard(redisSettings, "orphan", 10))
+	c.Assert(err, gocheck.NotNil)
+}
@@ -0,0 +1,184 @@
+package leaderboard
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+type RankPoint struct {
+	Time  time.Time
+	Rank  int
+	Score int
+}
+
+// RankHistory keeps a time series of rank and score samples per member of a
+// leaderboard. Samples older than Retention are dropped. When Resolution is
+// set, Compact keeps a single sample per Resolution for the samples older
+// than DownsampleAfter.
+type RankHistory struct {
+	Leaderboard     Leaderboard
+	Retention       time.Duration
+	Resolution      time.Duration
+	DownsampleAfter time.Duration
+}
+
+/* End Structs model */
+
+/* Private functions */
+
+func (h *RankHistory) key(member string) string {
+	return h.Leaderboard.subKey(":history:" + member)
+}
+
+func encodeRankPoint(p RankPoint) string {
+	return fmt.Sprintf("%d:%d:%d", p.Time.Unix(), p.Rank, p.Score)
+}
+
+func decodeRankPoint(value string) (RankPoint, error) {
+	var unix int64
+	var p RankPoint
+	if _, err := fmt.Sscanf(value, "%d:%d:%d", &unix, &p.Rank, &p.Score); err != nil {
+		return p, err
+	}
+	p.Time = time.Unix(unix, 0)
+	return p, nil
+}
+
+// store appends one sample per user and trims the series to the retention.
+func (h *RankHistory) store(conn redis.Conn, at time.Time, users []User) error {
+	oldest := at.Add(-h.Retention).Unix()
+	for _, user := range users {
+		key := h.key(user.Name)
+		point := RankPoint{Time: at, Rank: user.Rank, Score: user.Score}
+		conn.Send("ZADD", key, at.Unix(), encodeRankPoint(point))
+		if h.Retention > 0 {
+			conn.Send("ZREMRANGEBYSCORE", key, "-inf", "("+fmt.Sprint(oldest))
+			conn.Send("EXPIRE", key, int64(h.Retention/time.Second)+1)
+		}
+	}
+	_, err := doPipeline(conn)
+	return err
+}
+
+// sampleTop stores a sample of the n first placed players and returns them.
+func (h *RankHistory) sampleTop(at time.Time, n int) ([]User, error) {
+	if n < 1 {
+		return []User{}, nil
+	}
+	players := h.Leaderboard.topPlayers(n)
+	conn := h.Leaderboard.conn()
+	defer conn.Close()
+	return players, h.store(conn, at, players)
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// NewRankHistory creates a rank history for l. A zero retention keeps samples
+// forever.
+func NewRankHistory(l Leaderboard, retention time.Duration) RankHistory {
+	return RankHistory{Leaderboard: l, Retention: retention}
+}
+
+// Sample records the current rank and score of members at the given time.
+// Members absent from the leaderboard are skipped.
+func (h *RankHistory) Sample(at time.Time, members ...string) error {
+	conn := h.Leaderboard.conn()
+	defer conn.Close()
+	for _, member := range members {
+		if err := h.Leaderboard.sendMember(conn, member); err != nil {
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	users := make([]User, 0, len(members))
+	var failed error
+	for _, member := range members {
+		user, err := receiveMember(conn, member)
+		if err == redis.ErrNil {
+			continue
+		}
+		if err != nil {
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+		users = append(users, user)
+	}
+	if failed != nil {
+		return failed
+	}
+	return h.store(conn, at, users)
+}
+
+// SampleTop records the rank and score of the n first placed players at the
+// given time. Ghosts are not sampled.
+func (h *RankHistory) SampleTop(at time.Time, n int) error {
+	_, err := h.sampleTop(at, n)
+	return err
+}
+
+// Points returns the samples of member between two times, oldest first.
+func (h *RankHistory) Points(member string, from time.Time, to time.Time) ([]RankPoint, error) {
+	conn := h.Leaderboard.conn()
+	defer conn.Close()
+	values, err := redis.Strings(conn.Do("ZRANGEBYSCORE", h.key(member), from.Unix(), to.Unix()))
+	if err != nil {
+		return nil, err
+	}
+	points := make([]RankPoint, 0, len(values))
+	for _, value := range values {
+		point, err := decodeRankPoint(value)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// Compact downsamples the samples of member older than DownsampleAfter,
+// keeping the latest sample of every Resolution. It does nothing when
+// Resolution is not set.
+func (h *RankHistory) Compact(member string, now time.Time) error {
+	if h.Resolution < time.Second {
+		return nil
+	}
+	conn := h.Leaderboard.conn()
+	defer conn.Close()
+	key := h.key(member)
+	cutoff := now.Add(-h.DownsampleAfter).Unix()
+	values, err := redis.Strings(conn.Do("ZRANGEBYSCORE", key, "-inf", "("+fmt.Sprint(cutoff)))
+	if err != nil {
+		return err
+	}
+	resolution := int64(h.Resolution / time.Second)
+	args := redis.Args{}.Add(key)
+	for i := 0; i+1 < len(values); i++ {
+		current, err := decodeRankPoint(values[i])
+		if err != nil {
+			return err
+		}
+		next, err := decodeRankPoint(values[i+1])
+		if err != nil {
+			return err
+		}
+		if current.Time.Unix()/resolution == next.Time.Unix()/resolution {
+			args = args.Add(values[i])
+		}
+	}
+	if len(args) > 1 {
+		_, err = conn.Do("ZREM", args...)
+	}
+	return err
+}
+
+/* End Public functions */
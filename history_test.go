@@ -0,0 +1,76 @@
+package leaderboard
+
+import (
+	"time"
+
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestRankHistory(c *gocheck.C) {
+	season := NewLeaderboard(redisSettings, "season", 10)
+	history := NewRankHistory(season, 0)
+	start := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	season.RankMember("dayvson", 100)
+	season.RankMember("arthur", 200)
+	c.Assert(history.Sample(start, "dayvson", "unknown"), gocheck.IsNil)
+	season.RankMember("dayvson", 300)
+	c.Assert(history.SampleTop(start.Add(time.Hour), 5), gocheck.IsNil)
+
+	points, err := history.Points("dayvson", start, start.Add(time.Hour))
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(points), gocheck.Equals, 2)
+	c.Assert(points[0].Rank, gocheck.Equals, 2)
+	c.Assert(points[0].Score, gocheck.Equals, 100)
+	c.Assert(points[1].Rank, gocheck.Equals, 1)
+	c.Assert(points[1].Time.Equal(start.Add(time.Hour)), gocheck.Equals, true)
+
+	points, _ = history.Points("arthur", start, start.Add(time.Hour))
+	c.Assert(len(points), gocheck.Equals, 1)
+	points, _ = history.Points("unknown", start, start.Add(time.Hour))
+	c.Assert(len(points), gocheck.Equals, 0)
+}
+
+func (s *S) TestRankHistorySkipsGhosts(c *gocheck.C) {
+	season, _ := New(redisSettings, "ghostSeason", WithGhostPolicy(GhostsUnranked))
+	history := NewRankHistory(season, 0)
+	start := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	season.AddGhost("developer", 500)
+	season.RankMember("dayvson", 100)
+	season.RankMember("arthur", 200)
+	c.Assert(history.SampleTop(start, 2), gocheck.IsNil)
+	points, _ := history.Points("developer", start, start)
+	c.Assert(len(points), gocheck.Equals, 0)
+	points, _ = history.Points("dayvson", start, start)
+	c.Assert(points, gocheck.DeepEquals, []RankPoint{{Time: time.Unix(start.Unix(), 0), Rank: 2, Score: 100}})
+
+	conn := season.conn()
+	conn.Do("SET", history.key("arthur"), "taken")
+	conn.Close()
+	c.Assert(history.Sample(start, "arthur"), gocheck.ErrorMatches, "WRONGTYPE.*")
+}
+
+func (s *S) TestRankHistoryRetentionAndCompact(c *gocheck.C) {
+	season := NewLeaderboard(redisSettings, "compactSeason", 10)
+	history := NewRankHistory(season, 48*time.Hour)
+	history.Resolution = time.Hour
+	history.DownsampleAfter = 24 * time.Hour
+	start := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+	season.RankMember("felipe", 10)
+	for i := 0; i < 12; i++ {
+		history.Sample(start.Add(time.Duration(i)*15*time.Minute), "felipe")
+	}
+	now := start.Add(30 * time.Hour)
+	history.Sample(now, "felipe")
+
+	c.Assert(history.Compact("felipe", now), gocheck.IsNil)
+	points, _ := history.Points("felipe", start, now)
+	c.Assert(len(points), gocheck.Equals, 4)
+	c.Assert(points[0].Time.Equal(start.Add(45*time.Minute)), gocheck.Equals, true)
+
+	history.Sample(start.Add(60*time.Hour), "felipe")
+	points, _ = history.Points("felipe", start, start.Add(60*time.Hour))
+	c.Assert(len(points), gocheck.Equals, 2)
+	c.Assert(points[0].Time.Equal(now), gocheck.Equals, true)
+}
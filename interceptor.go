@@ -0,0 +1,111 @@
+package leaderboard
+
+/* Structs model */
+
+// Invocation describes a leaderboard operation going through interceptors:
+// the name of the method called, e.g. "RankMember", and its arguments in
+// order. Methods of the types built on a leaderboard are named after their
+// type, e.g. "Entries.RankEntry". Interceptors may replace arguments,
+// keeping their types.
+type Invocation struct {
+	Leaderboard *Leaderboard
+	Operation   string
+	Args        []interface{}
+}
+
+// Handler runs an invocation and returns the result of the operation: the
+// value returned by the method, e.g. a User, a []User or an int, or the
+// future returned by the Batch methods.
+type Handler func(inv *Invocation) (interface{}, error)
+
+// Interceptor wraps the operations of a leaderboard, like a gRPC interceptor.
+// It runs code before and after calling next, and may change the arguments
+// or the result, or short-circuit the operation by not calling next at all.
+// Operations without an error in their signature log the error returned by
+// an interceptor and return a zero value.
+//
+// Every method of Leaderboard reading or ranking members goes through the
+// interceptors, Stats and Version included, as do the writes made through
+// the other types:
+//
+//	AddGhost, SetMemberData, MergeMembers (once per registered leaderboard)
+//	Batch.RankMember, Batch.RemoveMember (when queued, not on Exec)
+//	DailyBoard.SetMemberZone (DailyBoard.RankMember runs RankMember on the day)
+//	Entries.RankEntry, Entries.RemoveEntry, Entries.RemoveAccount
+//	Moderation.Submit, Moderation.Approve, Moderation.Reject
+//	ReplicatedBoard.Submit, ReplicatedBoard.Remove
+//	StreakBoard.RecordActivity (with the options of NewStreakBoard)
+//
+// Maintenance writes are not intercepted: migrations, cleanups and sweeps,
+// the updates replayed by a Replicator and the scores a Registry propagates
+// to parent leaderboards.
+type Interceptor func(inv *Invocation, next Handler) (interface{}, error)
+
+/* End Structs model */
+
+// operationReturnsError lists the operations reporting interceptor errors to
+// their caller rather than to the logger.
+var operationReturnsError = map[string]bool{
+	"RankMember":                 true,
+	"RemoveMember":               true,
+	"GetMember":                  true,
+	"GetMemberData":              true,
+	"FilterRange":                true,
+	"GetFilteredLeaders":         true,
+	"GetSegmentLeaders":          true,
+	"IsGhost":                    true,
+	"Stats":                      true,
+	"Version":                    true,
+	"AddGhost":                   true,
+	"SetMemberData":              true,
+	"MergeMembers":               true,
+	"Batch.RankMember":           true,
+	"Batch.RemoveMember":         true,
+	"DailyBoard.SetMemberZone":   true,
+	"Entries.RankEntry":          true,
+	"Entries.RemoveEntry":        true,
+	"Entries.RemoveAccount":      true,
+	"Moderation.Submit":          true,
+	"Moderation.Approve":         true,
+	"Moderation.Reject":          true,
+	"ReplicatedBoard.Submit":     true,
+	"ReplicatedBoard.Remove":     true,
+	"StreakBoard.RecordActivity": true,
+}
+
+/* Private functions */
+
+// invoke runs an operation through the interceptors of the leaderboard.
+func (l *Leaderboard) invoke(operation string, args []interface{}, run func(args []interface{}) (interface{}, error)) (interface{}, error) {
+	if len(l.interceptors) == 0 {
+		return run(args)
+	}
+	handler := func(inv *Invocation) (interface{}, error) {
+		return run(inv.Args)
+	}
+	for i := len(l.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := l.interceptors[i], handler
+		handler = func(inv *Invocation) (interface{}, error) {
+			return interceptor(inv, next)
+		}
+	}
+	result, err := handler(&Invocation{Leaderboard: l, Operation: operation, Args: args})
+	if err != nil && !operationReturnsError[operation] {
+		l.logf("error on %s Leaderboard:%s - %s", operation, l.Name, err)
+	}
+	return result, err
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// WithInterceptors runs every operation of the leaderboard through
+// interceptors, the first one being the outermost.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(l *Leaderboard) {
+		l.interceptors = append(l.interceptors, interceptors...)
+	}
+}
+
+/* End Public functions */
@@ -0,0 +1,184 @@
+package leaderboard
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestWithInterceptors(c *gocheck.C) {
+	calls := []string{}
+	audit := func(inv *Invocation, next Handler) (interface{}, error) {
+		calls = append(calls, "before "+inv.Operation)
+		result, err := next(inv)
+		calls = append(calls, "after "+inv.Operation)
+		return result, err
+	}
+	lowerCase := func(inv *Invocation, next Handler) (interface{}, error) {
+		if inv.Operation == "RankMember" {
+			inv.Args[0] = strings.ToLower(inv.Args[0].(string))
+		}
+		return next(inv)
+	}
+	errNegative := errors.New("negative score")
+	validate := func(inv *Invocation, next Handler) (interface{}, error) {
+		if inv.Operation == "RankMember" && inv.Args[1].(int) < 0 {
+			return User{}, errNegative
+		}
+		return next(inv)
+	}
+	logger := &recordingLogger{}
+	board, _ := New(redisSettings, "intercepted", WithInterceptors(audit, lowerCase, validate), WithLogger(logger))
+
+	user, err := board.RankMember("Dayvson", 10)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Name, gocheck.Equals, "dayvson")
+	c.Assert(calls, gocheck.DeepEquals, []string{"before RankMember", "after RankMember"})
+
+	_, err = board.RankMember("arthur", -1)
+	c.Assert(err, gocheck.Equals, errNegative)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 1)
+	c.Assert(calls[len(calls)-1], gocheck.Equals, "after TotalMembers")
+
+	board.GetLeaders(1)
+	c.Assert(calls[len(calls)-2:], gocheck.DeepEquals, []string{"before GetLeaders", "after GetLeaders"})
+	c.Assert(len(logger.lines), gocheck.Equals, 0)
+}
+
+func (s *S) TestInterceptorShortCircuit(c *gocheck.C) {
+	cached := map[string]User{"felipe": {Name: "felipe", Score: 99, Rank: 1}}
+	cache := func(inv *Invocation, next Handler) (interface{}, error) {
+		if inv.Operation == "GetMember" {
+			if user, ok := cached[inv.Args[0].(string)]; ok {
+				return user, nil
+			}
+		}
+		return next(inv)
+	}
+	unavailable := func(inv *Invocation, next Handler) (interface{}, error) {
+		if inv.Operation == "TotalMembers" {
+			return nil, errors.New("maintenance")
+		}
+		return next(inv)
+	}
+	logger := &recordingLogger{}
+	board, _ := New(redisSettings, "intercepted", WithInterceptors(cache, unavailable), WithLogger(logger))
+	user, err := board.GetMember("felipe")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Score, gocheck.Equals, 99)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 0)
+	c.Assert(logger.lines, gocheck.DeepEquals, []string{"error on TotalMembers Leaderboard:intercepted - maintenance"})
+}
+
+func (s *S) TestInterceptedWrites(c *gocheck.C) {
+	operations := []string{}
+	errFrozen := errors.New("frozen")
+	freeze := func(inv *Invocation, next Handler) (interface{}, error) {
+		operations = append(operations, inv.Operation)
+		return nil, errFrozen
+	}
+	board, _ := New(redisSettings, "interceptedWrites", WithInterceptors(freeze))
+	registry := NewRegistry()
+	registry.Register(board)
+	entries := NewEntries(board)
+	moderation := NewModeration(board, 3)
+	replicated := NewReplicatedBoard(board, UpdateLastWrite, "west", 0)
+	daily := NewDailyBoard(board, time.UTC, 0)
+
+	_, err := board.AddGhost("developer", 10)
+	c.Assert(err, gocheck.Equals, errFrozen)
+	c.Assert(board.SetMemberData("dayvson", map[string]string{"country": "BR"}), gocheck.Equals, errFrozen)
+	_, err = registry.MergeMembers("felipe", "dayvson", MergeSum)
+	c.Assert(err, gocheck.Equals, errFrozen)
+	batch := NewBatch()
+	ranked := batch.RankMember(&board, "dayvson", 10)
+	removed := batch.RemoveMember(&board, "dayvson")
+	c.Assert(batch.Exec(), gocheck.IsNil)
+	_, err = ranked.Result()
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = removed.Result()
+	c.Assert(err, gocheck.Equals, errFrozen)
+	c.Assert(daily.SetMemberZone("dayvson", time.UTC), gocheck.Equals, errFrozen)
+	_, err = entries.RankEntry("acme", "dayvson", 10)
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = entries.RemoveEntry("dayvson")
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = entries.RemoveAccount("acme")
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, held, err := moderation.Submit("dayvson", 10, "video")
+	c.Assert(err, gocheck.Equals, errFrozen)
+	c.Assert(held, gocheck.Equals, false)
+	_, err = moderation.Approve("dayvson")
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = moderation.Reject("dayvson")
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = replicated.Submit("dayvson", 10)
+	c.Assert(err, gocheck.Equals, errFrozen)
+	c.Assert(replicated.Remove("dayvson"), gocheck.Equals, errFrozen)
+
+	c.Assert(operations, gocheck.DeepEquals, []string{
+		"AddGhost", "SetMemberData", "MergeMembers", "Batch.RankMember", "Batch.RemoveMember",
+		"DailyBoard.SetMemberZone", "Entries.RankEntry", "Entries.RemoveEntry", "Entries.RemoveAccount",
+		"Moderation.Submit", "Moderation.Approve", "Moderation.Reject", "ReplicatedBoard.Submit", "ReplicatedBoard.Remove",
+	})
+	conn := board.conn()
+	defer conn.Close()
+	written, _ := redis.Strings(conn.Do("KEYS", "interceptedWrites*"))
+	c.Assert(written, gocheck.DeepEquals, []string{})
+}
+
+func (s *S) TestInterceptReads(c *gocheck.C) {
+	errFrozen := errors.New("frozen")
+	operations := []string{}
+	freeze := func(inv *Invocation, next Handler) (interface{}, error) {
+		operations = append(operations, inv.Operation)
+		return nil, errFrozen
+	}
+	logger := &recordingLogger{}
+	board, _ := New(redisSettings, "interceptedReads", WithInterceptors(freeze), WithLogger(logger),
+		WithSegment("br", Filter{"country": {"BR"}}))
+
+	_, err := board.GetMemberData("dayvson")
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = board.FilterRange(Filter{}, 0, 10)
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = board.GetFilteredLeaders(Filter{}, 1)
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = board.GetSegmentLeaders("br", 1)
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = board.IsGhost("dayvson")
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = board.Stats()
+	c.Assert(err, gocheck.Equals, errFrozen)
+	_, err = board.Version()
+	c.Assert(err, gocheck.Equals, errFrozen)
+	c.Assert(board.TotalPlayers(), gocheck.Equals, 0)
+	c.Assert(board.TopPlayers(3), gocheck.HasLen, 0)
+
+	c.Assert(operations, gocheck.DeepEquals, []string{
+		"GetMemberData", "FilterRange", "GetFilteredLeaders", "GetSegmentLeaders", "IsGhost", "Stats", "Version",
+		"TotalPlayers", "TopPlayers",
+	})
+	c.Assert(logger.lines, gocheck.HasLen, 2)
+}
+
+func (s *S) TestCountSubmissionsOfEveryWritePath(c *gocheck.C) {
+	board, _ := New(redisSettings, "interceptedCount", WithInterceptors(CountSubmissions()))
+	board.RankMember("dayvson", 10)
+	batch := NewBatch()
+	batch.RankMember(&board, "felipe", 20)
+	c.Assert(batch.Exec(), gocheck.IsNil)
+	entries := NewEntries(board)
+	entries.RankEntry("acme", "arthur", 30)
+	moderation := NewModeration(board, 1)
+	moderation.Submit("ana", 40, "video")
+	replicated := NewReplicatedBoard(board, UpdateMax, "west", 0)
+	replicated.Submit("bia", 5)
+
+	stats, err := board.Stats()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(stats.Submissions, gocheck.Equals, int64(5))
+}
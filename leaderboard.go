@@ -3,6 +3,8 @@ package leaderboard
 import (
 	"fmt"
 	"math"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/garyburd/redigo/redis"
@@ -13,6 +15,7 @@ type User struct {
 	Name  string
 	Score int
 	Rank  int
+	Ghost bool
 }
 
 type Team struct {
@@ -26,22 +29,170 @@ type RedisSettings struct {
 	Password string
 }
 
+// SortOrder tells which end of the score range is ranked first.
+type SortOrder int
+
+const (
+	// HighToLow ranks the highest score first. It is the default order.
+	HighToLow SortOrder = iota
+	// LowToHigh ranks the lowest score first, e.g. for best times.
+	LowToHigh
+)
+
+// TiePolicy tells how members with equal scores are ranked.
+type TiePolicy int
+
+const (
+	// TieByMember gives equal scores distinct ranks in the order Redis keeps
+	// them, i.e. by member name. It is the default policy.
+	TieByMember TiePolicy = iota
+	// TieShared gives equal scores the same rank, skipping the following
+	// ranks ("1224" ranking).
+	TieShared
+)
+
 type Leaderboard struct {
 	Settings RedisSettings
 	Name     string
 	PageSize int
+	Order    SortOrder
+
+	ties      TiePolicy
+	ghosts    GhostPolicy
+	aggregate Aggregation
+	keyPrefix string
+	derived   string
+	logger    Logger
+	metrics   Metrics
+	backend   Backend
+	clock     Clock
+	top       *topSnapshot
+	windows   []Window
+	cap       int
+	segments  map[string]Filter
+	entries   bool
+
+	interceptors []Interceptor
+}
+
+// rankSource answers the lookups that share ranks and place ghosts, either
+// on Redis or on a client-side cache, so both read paths rank alike.
+type rankSource interface {
+	// countBefore counts, for each score, the members of the sorted set key
+	// placed strictly before it in the order of the leaderboard.
+	countBefore(key string, scores []int) ([]int, error)
+	// holds tells, for each member, whether the sorted set key holds it.
+	holds(key string, members []string) ([]bool, error)
+	// holding returns, for each score, the members of the sorted set key
+	// holding it.
+	holding(key string, scores []int) ([][]string, error)
+	// positions returns the positions of members on the leaderboard, counted
+	// from zero in its order, or -1 for the members it does not rank.
+	positions(members []string) ([]int, error)
+}
+
+// connRanks is the rankSource reading Redis through a connection.
+type connRanks struct {
+	l    *Leaderboard
+	conn redis.Conn
 }
 
 /* End Structs model */
 
-var pool *redis.Pool
+// DefaultPageSize is used when a leaderboard is created without a page size.
+const DefaultPageSize = 25
+
+// poolIdleTimeout is how long a pooled connection may sit idle before it is
+// dropped on its next borrow.
+const poolIdleTimeout = 240 * time.Second
+
+// pools holds the connection pools shared by the leaderboards created from
+// RedisSettings, one per clock, so that idle timeouts follow the clock of
+// the leaderboards using them.
+var (
+	poolsMu sync.Mutex
+	pools   = map[Clock]*redis.Pool{}
+)
+
+// memberScript returns the score, rank and ghost flag of a member in one
+// round trip, or nil when the member is not ranked.
+// KEYS: board, ghosts.
+// ARGV: member, sort order, tie policy, ghost policy.
+var memberScript = redis.NewScript(2, ghostsLua+`
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score then
+	return false
+end
+local rank
+if ARGV[3] == '1' then
+	if ARGV[2] == '1' then
+		rank = redis.call('ZCOUNT', KEYS[1], '-inf', '(' .. score)
+	else
+		rank = redis.call('ZCOUNT', KEYS[1], '(' .. score, '+inf')
+	end
+elseif ARGV[2] == '1' then
+	rank = redis.call('ZRANK', KEYS[1], ARGV[1])
+else
+	rank = redis.call('ZREVRANK', KEYS[1], ARGV[1])
+end
+local ghost = 0
+if ARGV[4] ~= '0' then
+	ghost = redis.call('ZSCORE', KEYS[2], ARGV[1]) and 1 or 0
+end
+if ARGV[4] == '2' then
+	if ghost == 1 then
+		return {score, 0, 1}
+	end
+	rank = rank - ghostsBefore(rank, tonumber(score), ARGV[2], ARGV[3])
+end
+return {score, rank + 1, ghost}
+`)
+
+// trimLua removes the members ranked past the cap, along with their ghost
+// flags and metadata, and returns them.
+// ARGV: cap, sort order.
+const trimLua = `
+local function trim(board, ghosts, metadata)
+	local command = 'ZREVRANGE'
+	if ARGV[2] == '1' then command = 'ZRANGE' end
+	local members = redis.call(command, board, ARGV[1], -1)
+	for i = 1, #members, 500 do
+		local batch = {unpack(members, i, math.min(i + 499, #members))}
+		redis.call('ZREM', board, unpack(batch))
+		redis.call('ZREM', ghosts, unpack(batch))
+		redis.call('HDEL', metadata, unpack(batch))
+	end
+	return members
+end
+`
+
+// trimScript trims the board to the cap and returns how many members were
+// removed.
+// KEYS: board, ghosts, metadata.
+var trimScript = redis.NewScript(3, trimLua+`
+return #trim(KEYS[1], KEYS[2], KEYS[3])
+`)
+
+// trimEntriesScript trims a board keeping entries to the cap, forgetting the
+// accounts of the entries removed, and returns how many were removed.
+// KEYS: board, entry accounts, account entries, best board, best entries,
+// ghosts, metadata.
+var trimEntriesScript = redis.NewScript(7, entriesLua+trimLua+`
+local members = trim(KEYS[1], KEYS[6], KEYS[7])
+for _, member in ipairs(members) do
+	drop(member, ARGV[2])
+end
+return #members
+`)
 
 /* Private functions */
 
-func newPool(server string, password string) *redis.Pool {
+// newPool returns a pool of connections to server. Idle connections are
+// timed by clock, which redigo cannot be given, so each connection records
+// when it was last used.
+func newPool(server string, password string, clock Clock) *redis.Pool {
 	return &redis.Pool{
-		MaxIdle:     10,
-		IdleTimeout: 240 * time.Second,
+		MaxIdle: 10,
 		Dial: func() (redis.Conn, error) {
 			c, err := redis.Dial("tcp", server)
 			if err != nil {
@@ -53,136 +204,431 @@ func newPool(server string, password string) *redis.Pool {
 					return nil, err
 				}
 			}
-			return c, err
+			return &clockedConn{Conn: c, clock: clock, used: clock.Now()}, nil
 		},
 		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			if conn, ok := c.(*clockedConn); ok && clock.Now().Sub(conn.used) > poolIdleTimeout {
+				return errIdleTimeout
+			}
 			_, err := c.Do("PING")
 			return err
 		},
 	}
 }
 
-func getConnection(settings RedisSettings) redis.Conn {
-	if pool == nil {
-		pool = newPool(settings.Host, settings.Password)
+// doPipeline flushes the commands queued on conn and returns their replies
+// along with the first error among them, since redigo hands the error
+// replies of a pipeline back as values.
+func doPipeline(conn redis.Conn) ([]interface{}, error) {
+	reply, err := conn.Do("")
+	replies, _ := reply.([]interface{})
+	if err != nil {
+		return replies, err
+	}
+	for _, reply := range replies {
+		if err, ok := reply.(redis.Error); ok {
+			return replies, err
+		}
+	}
+	return replies, nil
+}
+
+func getConnection(settings RedisSettings, clock Clock) redis.Conn {
+	poolsMu.Lock()
+	pool, ok := pools[clock]
+	if !ok {
+		pool = newPool(settings.Host, settings.Password, clock)
+		pools[clock] = pool
 	}
+	poolsMu.Unlock()
 	return pool.Get()
 }
 
-func getMembersByRange(settings RedisSettings, leaderboard string, pageSize int, startOffset int, endOffset int) []User {
-	conn := getConnection(settings)
+// conn returns a connection from the leaderboard backend, or from the shared
+// pool when no backend was given.
+func (l *Leaderboard) conn() redis.Conn {
+	var conn redis.Conn
+	if l.backend != nil {
+		conn = l.backend.Get()
+	} else {
+		conn = getConnection(l.Settings, l.clockOrSystem())
+	}
+	if l.metrics != nil {
+		conn = &instrumentedConn{Conn: conn, metrics: l.metrics}
+	}
+	return conn
+}
+
+// key is the Redis key holding the leaderboard.
+func (l *Leaderboard) key() string {
+	if l.derived != "" {
+		return l.derived
+	}
+	return l.keyPrefix + l.Name
+}
+
+// subKey is the Redis key of suffix under the key of the leaderboard. Unless
+// that key already has a hash tag, it is used as one so the keys of a
+// leaderboard land on the same Redis Cluster slot.
+func (l *Leaderboard) subKey(suffix string) string {
+	key := l.key()
+	if hashTagged(key) {
+		return key + suffix
+	}
+	return "{" + key + "}" + suffix
+}
+
+// hashTagged tells whether Redis Cluster hashes key by a part of it between
+// braces.
+func hashTagged(key string) bool {
+	open := strings.IndexByte(key, '{')
+	return open >= 0 && strings.IndexByte(key[open+1:], '}') > 0
+}
+
+func (l *Leaderboard) clockOrSystem() Clock {
+	if l.clock != nil {
+		return l.clock
+	}
+	return SystemClock
+}
+
+func (l *Leaderboard) now() time.Time {
+	return l.clockOrSystem().Now()
+}
+
+func (l *Leaderboard) logf(format string, v ...interface{}) {
+	if l.logger != nil {
+		l.logger.Printf(format, v...)
+		return
+	}
+	fmt.Printf(format, v...)
+}
+
+// derive returns a leaderboard sharing the settings and options of l, named
+// after l followed by suffix and stored under the matching subKey. The top
+// snapshot and the windows are not shared.
+func (l *Leaderboard) derive(suffix string) Leaderboard {
+	d := *l
+	d.Name = l.Name + suffix
+	d.derived = l.subKey(suffix)
+	d.top = nil
+	d.windows = nil
+	return d
+}
+
+// sendTrim queues the removal of the members ranked past the cap of the
+// leaderboard, if it has one, and returns how many replies to read back.
+func (l *Leaderboard) sendTrim(conn redis.Conn) int {
+	if l.cap == 0 {
+		return 0
+	}
+	if l.entries {
+		trimEntriesScript.Send(conn, append(l.entryKeys(), l.ghostsKey(), l.metaKey(), l.cap, int(l.Order))...)
+		return 1
+	}
+	trimScript.Send(conn, l.key(), l.ghostsKey(), l.metaKey(), l.cap, int(l.Order))
+	return 1
+}
+
+// sendScore queues the ranking of a member: its score, the score of the
+// ghost it may be, the trim to the cap, the best entry of its account and
+// the windows. It returns how many replies to read back, the first one being
+// the reply to the score.
+func (l *Leaderboard) sendScore(conn redis.Conn, member string, score int) int {
+	conn.Send("ZADD", l.key(), score, member)
+	conn.Send("ZADD", l.ghostsKey(), "XX", score, member)
+	return 2 + l.sendTrim(conn) + l.sendEntryScore(conn, member) + l.sendWindows(conn, member, score)
+}
+
+// sendRemoval queues the removal of a member with its ghost flag, metadata
+// and account. It returns how many replies to read back, the first one
+// being the reply to the removal.
+func (l *Leaderboard) sendRemoval(conn redis.Conn, member string) int {
+	conn.Send("ZREM", l.key(), member)
+	conn.Send("ZREM", l.ghostsKey(), member)
+	conn.Send("HDEL", l.metaKey(), member)
+	return 3 + l.sendEntryRemoval(conn, member)
+}
+
+func (l *Leaderboard) memberScriptArgs(member string) []interface{} {
+	return []interface{}{l.key(), l.ghostsKey(), member, int(l.Order), int(l.ties), int(l.ghosts)}
+}
+
+// sendMember queues the lookup of a member on a pipeline, to be read back
+// with receiveMember.
+func (l *Leaderboard) sendMember(conn redis.Conn, member string) error {
+	return memberScript.Send(conn, l.memberScriptArgs(member)...)
+}
+
+func (l *Leaderboard) getMember(conn redis.Conn, member string) (User, error) {
+	reply, err := memberScript.Do(conn, l.memberScriptArgs(member)...)
+	return parseMember(member, reply, err)
+}
+
+func receiveMember(conn redis.Conn, member string) (User, error) {
+	reply, err := conn.Receive()
+	return parseMember(member, reply, err)
+}
+
+// parseMember reads a memberScript reply. Members not ranked get a zero Rank
+// and redis.ErrNil.
+func parseMember(member string, reply interface{}, err error) (User, error) {
+	values, err := redis.Ints(reply, err)
+	if err != nil {
+		return User{Name: member}, err
+	}
+	return User{Name: member, Score: values[0], Rank: values[1], Ghost: values[2] == 1}, nil
+}
+
+// rangeCommand returns the command walking the board from the first rank, or
+// from the last rank when reverse is set.
+func (l *Leaderboard) rangeCommand(reverse bool) string {
+	if (l.Order == LowToHigh) != reverse {
+		return "ZRANGE"
+	}
+	return "ZREVRANGE"
+}
+
+// getMembersByRange returns the members between two offsets. Offsets count
+// from the first rank, or from the last rank when reverse is set.
+func (l *Leaderboard) getMembersByRange(pageSize int, startOffset int, endOffset int, reverse bool) []User {
+	conn := l.conn()
 	defer conn.Close()
 	users := make([]User, pageSize)
-	values, _ := redis.Values(conn.Do("ZREVRANGE", leaderboard, startOffset, endOffset, "WITHSCORES"))
+	total := 0
+	if reverse {
+		total, _ = redis.Int(conn.Do("ZCARD", l.key()))
+	}
+	values, err := redis.Values(conn.Do(l.rangeCommand(reverse), l.key(), startOffset, endOffset, "WITHSCORES"))
+	if err != nil {
+		l.logf("error on get members by range Leaderboard:%s - %s", l.Name, err)
+	}
 	var i = 0
-	for len(values) > 0 {
+	for len(values) > 0 && i < pageSize {
 		name := ""
 		score := -1
-		values, _ = redis.Scan(values, &name, &score)
-		rank, _ := redis.Int(conn.Do("ZREVRANK", leaderboard, name))
-		nUser := User{Name: name, Score: score, Rank: rank + 1}
+		if values, err = redis.Scan(values, &name, &score); err != nil {
+			l.logf("error on scan members by range Leaderboard:%s - %s", l.Name, err)
+			break
+		}
+		rank := startOffset + i + 1
+		if reverse {
+			rank = total - startOffset - i
+		}
+		nUser := User{Name: name, Score: score, Rank: rank}
 		users[i] = nUser
 		i += 1
 	}
+	if err := l.rankUsers(connRanks{l, conn}, users[:i]); err != nil {
+		l.logf("error on rank members by range Leaderboard:%s - %s", l.Name, err)
+	}
 	return users
 }
 
-/* End Private functions */
+// scoreRange returns the bounds of the scores placed strictly before score.
+func (l *Leaderboard) scoreRange(score int) (string, string) {
+	if l.Order == LowToHigh {
+		return "-inf", fmt.Sprintf("(%d", score)
+	}
+	return fmt.Sprintf("(%d", score), "+inf"
+}
 
-/* Public functions */
+// distinctScores returns the scores of users, each once, in order.
+func distinctScores(users []User) []int {
+	scores := []int{}
+	seen := map[int]bool{}
+	for _, user := range users {
+		if !seen[user.Score] {
+			seen[user.Score] = true
+			scores = append(scores, user.Score)
+		}
+	}
+	return scores
+}
 
-func NewLeaderboard(settings RedisSettings, name string, pageSize int) Leaderboard {
-	l := Leaderboard{Settings: settings, Name: name, PageSize: pageSize}
-	return l
+// shareRanks replaces the rank of users with the rank of the first member
+// holding the same score.
+func (l *Leaderboard) shareRanks(source rankSource, users []User) error {
+	scores := distinctScores(users)
+	better, err := source.countBefore(l.key(), scores)
+	if err != nil {
+		return err
+	}
+	ranks := map[int]int{}
+	for i, score := range scores {
+		ranks[score] = better[i] + 1
+	}
+	for i := range users {
+		users[i].Rank = ranks[users[i].Score]
+	}
+	return nil
 }
 
-func (l *Leaderboard) RankMember(username string, score int) (User, error) {
-	conn := getConnection(l.Settings)
-	defer conn.Close()
-	_, err := conn.Do("ZADD", l.Name, score, username)
+// rankUsers shares the ranks of users and places the ghosts among them, by
+// the policies of the leaderboard.
+func (l *Leaderboard) rankUsers(source rankSource, users []User) error {
+	if l.ties == TieShared {
+		if err := l.shareRanks(source, users); err != nil {
+			return err
+		}
+	}
+	return l.markGhosts(source, users)
+}
+
+// replies reads back the n replies of a pipeline.
+func (s connRanks) replies(n int) ([]interface{}, error) {
+	if n == 0 {
+		return []interface{}{}, nil
+	}
+	values, err := doPipeline(s.conn)
 	if err != nil {
-		fmt.Printf("error on store in redis in rankMember Leaderboard:%s - Username:%s - Score:%d", l.Name, username, score)
+		return nil, err
 	}
-	rank, err := redis.Int(conn.Do("ZREVRANK", l.Name, username))
+	if len(values) != n {
+		return nil, fmt.Errorf("leaderboard: unexpected reply of %d values to %d commands", len(values), n)
+	}
+	return values, nil
+}
+
+func (s connRanks) countBefore(key string, scores []int) ([]int, error) {
+	for _, score := range scores {
+		min, max := s.l.scoreRange(score)
+		s.conn.Send("ZCOUNT", key, min, max)
+	}
+	values, err := s.replies(len(scores))
 	if err != nil {
-		fmt.Printf("error on get user rank Leaderboard:%s - Username:%s", l.Name, username)
-		rank = -1
+		return nil, err
 	}
-	nUser := User{Name: username, Score: score, Rank: rank + 1}
-	return nUser, err
+	return redis.Ints(values, nil)
 }
 
-func (l *Leaderboard) TotalMembers() int {
-	conn := getConnection(l.Settings)
-	total, err := redis.Int(conn.Do("ZCARD", l.Name))
+func (s connRanks) holds(key string, members []string) ([]bool, error) {
+	for _, member := range members {
+		s.conn.Send("ZSCORE", key, member)
+	}
+	values, err := s.replies(len(members))
 	if err != nil {
-		fmt.Printf("error on get leaderboard total members")
-		return 0
+		return nil, err
 	}
-	defer conn.Close()
-	return total
+	held := make([]bool, len(values))
+	for i, value := range values {
+		held[i] = value != nil
+	}
+	return held, nil
 }
 
-func (l *Leaderboard) RemoveMember(username string) (User, error) {
-	conn := getConnection(l.Settings)
-	nUser, err := l.GetMember(username)
-	_, err = conn.Do("ZREM", l.Name, username)
+func (s connRanks) holding(key string, scores []int) ([][]string, error) {
+	for _, score := range scores {
+		s.conn.Send("ZRANGEBYSCORE", key, score, score)
+	}
+	values, err := s.replies(len(scores))
 	if err != nil {
-		fmt.Printf("error on remove user from leaderboard")
+		return nil, err
 	}
-	defer conn.Close()
-	return nUser, err
+	members := make([][]string, len(values))
+	for i, value := range values {
+		if members[i], err = redis.Strings(value, nil); err != nil {
+			return nil, err
+		}
+	}
+	return members, nil
 }
 
-func (l *Leaderboard) TotalPages() int {
-	conn := getConnection(l.Settings)
-	pages := 0
-	total, err := redis.Int(conn.Do("ZCOUNT", l.Name, "-inf", "+inf"))
-	if err == nil {
-		pages = int(math.Ceil(float64(total) / float64(l.PageSize)))
+func (s connRanks) positions(members []string) ([]int, error) {
+	command := "ZREVRANK"
+	if s.l.Order == LowToHigh {
+		command = "ZRANK"
 	}
-	defer conn.Close()
-	return pages
+	for _, member := range members {
+		s.conn.Send(command, s.l.key(), member)
+	}
+	values, err := s.replies(len(members))
+	if err != nil {
+		return nil, err
+	}
+	positions := make([]int, len(values))
+	for i, value := range values {
+		positions[i] = -1
+		if value != nil {
+			if positions[i], err = redis.Int(value, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return positions, nil
 }
 
-func (l *Leaderboard) GetMember(username string) (User, error) {
-	conn := getConnection(l.Settings)
-	rank, err := redis.Int(conn.Do("ZREVRANK", l.Name, username))
+func (l *Leaderboard) rankMember(username string, score int) (User, error) {
+	conn := l.conn()
+	defer conn.Close()
+	l.sendScore(conn, username, score)
+	if _, err := doPipeline(conn); err != nil {
+		l.logf("error on store in redis in rankMember Leaderboard:%s - Username:%s - Score:%d", l.Name, username, score)
+	}
+	nUser, err := l.getMember(conn, username)
 	if err != nil {
-		rank = 0
+		l.logf("error on get user rank Leaderboard:%s - Username:%s", l.Name, username)
 	}
-	score, err := redis.Int(conn.Do("ZSCORE", l.Name, username))
+	nUser.Score = score
+	return nUser, err
+}
+
+func (l *Leaderboard) totalMembers() int {
+	conn := l.conn()
+	defer conn.Close()
+	total, err := redis.Int(conn.Do("ZCARD", l.key()))
 	if err != nil {
-		score = 0
+		l.logf("error on get leaderboard total members")
+		return 0
 	}
+	return total
+}
+
+func (l *Leaderboard) removeMember(username string) (User, error) {
+	conn := l.conn()
 	defer conn.Close()
-	nUser := User{Name: username, Score: score, Rank: rank + 1}
+	nUser, err := l.getMember(conn, username)
+	l.sendRemoval(conn, username)
+	if _, err = doPipeline(conn); err != nil {
+		l.logf("error on remove user from leaderboard")
+	}
 	return nUser, err
 }
 
-func (l *Leaderboard) GetAroundMe(username string) []User {
-	currentUser, _ := l.GetMember(username)
+func (l *Leaderboard) totalPages() int {
+	conn := l.conn()
+	defer conn.Close()
+	pages := 0
+	total, err := redis.Int(conn.Do("ZCOUNT", l.key(), "-inf", "+inf"))
+	if err == nil {
+		pages = int(math.Ceil(float64(total) / float64(l.PageSize)))
+	}
+	return pages
+}
+
+func (l *Leaderboard) getAroundMe(username string) []User {
+	conn := l.conn()
+	currentUser, _ := l.getMember(conn, username)
+	conn.Close()
 	startOffset := currentUser.Rank - (l.PageSize / 2)
 	if startOffset < 0 {
 		startOffset = 0
 	}
 	endOffset := (startOffset + l.PageSize) - 1
-	return getMembersByRange(l.Settings, l.Name, l.PageSize, startOffset, endOffset)
-}
-
-func (l *Leaderboard) GetRank(username string) int {
-	conn := getConnection(l.Settings)
-	rank, _ := redis.Int(conn.Do("ZREVRANK", l.Name, username))
-	defer conn.Close()
-	return rank + 1
+	return l.getMembersByRange(l.PageSize, startOffset, endOffset, false)
 }
 
-func (l *Leaderboard) GetLeaders(page int) []User {
+func (l *Leaderboard) getLeaders(page int) []User {
 	if page < 1 {
 		page = 1
 	}
-	if page > l.TotalPages() {
-		page = l.TotalPages()
+	if l.top != nil {
+		if users, ok := l.getLeadersFromTop(page); ok {
+			return users
+		}
+	}
+	if page > l.totalPages() {
+		page = l.totalPages()
 	}
 	redisIndex := page - 1
 	startOffset := redisIndex * l.PageSize
@@ -190,23 +636,155 @@ func (l *Leaderboard) GetLeaders(page int) []User {
 		startOffset = 0
 	}
 	endOffset := (startOffset + l.PageSize) - 1
-	return getMembersByRange(l.Settings, l.Name, l.PageSize, startOffset, endOffset)
+	return l.getMembersByRange(l.PageSize, startOffset, endOffset, false)
 }
 
-func (l *Leaderboard) GetMemberByRank(position int) User {
-	conn := getConnection(l.Settings)
+func (l *Leaderboard) getLeadersReverse(page int) []User {
+	if page < 1 {
+		page = 1
+	}
+	if page > l.totalPages() {
+		page = l.totalPages()
+	}
+	startOffset := (page - 1) * l.PageSize
+	if startOffset < 0 {
+		startOffset = 0
+	}
+	endOffset := (startOffset + l.PageSize) - 1
+	return l.getMembersByRange(l.PageSize, startOffset, endOffset, true)
+}
 
-	if position <= l.TotalMembers() {
-		currentPage := int(math.Ceil(float64(position) / float64(l.PageSize)))
-		offset := (position - 1) % l.PageSize
-		leaders := l.GetLeaders(currentPage)
-		defer conn.Close()
-		if leaders[offset].Rank == position {
-			return leaders[offset]
-		}
+func (l *Leaderboard) bottom(n int) []User {
+	total := l.totalMembers()
+	if n > total {
+		n = total
 	}
-	defer conn.Close()
-	return User{}
+	if n < 1 {
+		return []User{}
+	}
+	return l.getMembersByRange(n, 0, n-1, true)
+}
+
+func (l *Leaderboard) getMemberByRank(position int) User {
+	if position < 1 || position > l.totalMembers() {
+		return User{}
+	}
+	return l.getMembersByRange(1, position-1, position-1, false)[0]
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// NewLeaderboard creates a leaderboard with the default options. A page size
+// below one falls back to DefaultPageSize; use New to get it validated.
+func NewLeaderboard(settings RedisSettings, name string, pageSize int) Leaderboard {
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	l := Leaderboard{Settings: settings, Name: name, PageSize: pageSize}
+	return l
+}
+
+func (l *Leaderboard) RankMember(username string, score int) (User, error) {
+	result, err := l.invoke("RankMember", []interface{}{username, score}, func(args []interface{}) (interface{}, error) {
+		return l.rankMember(args[0].(string), args[1].(int))
+	})
+	user, _ := result.(User)
+	return user, err
+}
+
+func (l *Leaderboard) TotalMembers() int {
+	result, _ := l.invoke("TotalMembers", nil, func(args []interface{}) (interface{}, error) {
+		return l.totalMembers(), nil
+	})
+	total, _ := result.(int)
+	return total
+}
+
+func (l *Leaderboard) RemoveMember(username string) (User, error) {
+	result, err := l.invoke("RemoveMember", []interface{}{username}, func(args []interface{}) (interface{}, error) {
+		return l.removeMember(args[0].(string))
+	})
+	user, _ := result.(User)
+	return user, err
+}
+
+func (l *Leaderboard) TotalPages() int {
+	result, _ := l.invoke("TotalPages", nil, func(args []interface{}) (interface{}, error) {
+		return l.totalPages(), nil
+	})
+	pages, _ := result.(int)
+	return pages
+}
+
+// GetMember returns the score and rank of a member. Members not ranked get a
+// zero Rank and redis.ErrNil.
+func (l *Leaderboard) GetMember(username string) (User, error) {
+	result, err := l.invoke("GetMember", []interface{}{username}, func(args []interface{}) (interface{}, error) {
+		conn := l.conn()
+		defer conn.Close()
+		return l.getMember(conn, args[0].(string))
+	})
+	user, _ := result.(User)
+	return user, err
+}
+
+func (l *Leaderboard) GetAroundMe(username string) []User {
+	result, _ := l.invoke("GetAroundMe", []interface{}{username}, func(args []interface{}) (interface{}, error) {
+		return l.getAroundMe(args[0].(string)), nil
+	})
+	users, _ := result.([]User)
+	return users
+}
+
+// GetRank returns the rank of a member, or 0 when it is not ranked.
+func (l *Leaderboard) GetRank(username string) int {
+	result, _ := l.invoke("GetRank", []interface{}{username}, func(args []interface{}) (interface{}, error) {
+		conn := l.conn()
+		defer conn.Close()
+		user, _ := l.getMember(conn, args[0].(string))
+		return user.Rank, nil
+	})
+	rank, _ := result.(int)
+	return rank
+}
+
+func (l *Leaderboard) GetLeaders(page int) []User {
+	result, _ := l.invoke("GetLeaders", []interface{}{page}, func(args []interface{}) (interface{}, error) {
+		return l.getLeaders(args[0].(int)), nil
+	})
+	users, _ := result.([]User)
+	return users
+}
+
+// GetLeadersReverse pages from the bottom of the leaderboard: page 1 holds the
+// last placed members, starting with the very last one.
+func (l *Leaderboard) GetLeadersReverse(page int) []User {
+	result, _ := l.invoke("GetLeadersReverse", []interface{}{page}, func(args []interface{}) (interface{}, error) {
+		return l.getLeadersReverse(args[0].(int)), nil
+	})
+	users, _ := result.([]User)
+	return users
+}
+
+// Bottom returns the n last placed members, starting with the very last one.
+func (l *Leaderboard) Bottom(n int) []User {
+	result, _ := l.invoke("Bottom", []interface{}{n}, func(args []interface{}) (interface{}, error) {
+		return l.bottom(args[0].(int)), nil
+	})
+	users, _ := result.([]User)
+	return users
+}
+
+// GetMemberByRank returns the member placed at a position, counted from 1.
+// With shared ties, the returned Rank may be lower than the position.
+func (l *Leaderboard) GetMemberByRank(position int) User {
+	result, _ := l.invoke("GetMemberByRank", []interface{}{position}, func(args []interface{}) (interface{}, error) {
+		return l.getMemberByRank(args[0].(int)), nil
+	})
+	user, _ := result.(User)
+	return user
 }
 
 /* End Public functions */
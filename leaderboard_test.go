@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/alicebob/miniredis/v2"
 	"launchpad.net/gocheck"
 )
 
@@ -11,7 +12,10 @@ func Test(t *testing.T) {
 	gocheck.TestingT(t)
 }
 
-type S struct{}
+type S struct {
+	// servers are the Redis servers a test started, closed after it.
+	servers []*miniredis.Miniredis
+}
 
 var _ = gocheck.Suite(&S{})
 var redisSettings = RedisSettings{
@@ -19,9 +23,16 @@ var redisSettings = RedisSettings{
 	Password: "",
 }
 
+func (s *S) TearDownTest(c *gocheck.C) {
+	for _, server := range s.servers {
+		server.Close()
+	}
+	s.servers = nil
+}
+
 func (s *S) TearDownSuite(c *gocheck.C) {
 
-	conn := getConnection(redisSettings)
+	conn := getConnection(redisSettings, SystemClock)
 	conn.Do("DEL", "highscore")
 	conn.Do("DEL", "bestTime")
 	conn.Do("DEL", "bestWeek")
@@ -29,6 +40,58 @@ func (s *S) TearDownSuite(c *gocheck.C) {
 	conn.Do("DEL", "7days")
 	conn.Do("DEL", "bestYear")
 	conn.Do("DEL", "week")
+	conn.Do("DEL", "worstYear")
+	conn.Do("DEL", "lapTime")
+	conn.Do("DEL", "compareArena")
+	conn.Do("DEL", "compareRace")
+	conn.Do("DEL", "season", "{season}:history:dayvson", "{season}:history:arthur")
+	conn.Do("DEL", "ghostSeason", "{ghostSeason}:ghosts", "{ghostSeason}:history:dayvson", "{ghostSeason}:history:arthur")
+	conn.Do("DEL", "compactSeason", "{compactSeason}:history:felipe")
+	conn.Do("DEL", "game:prefixed", "sharedTies", "bestLap", "observed")
+	conn.Do("DEL", "replicatedTop", "{replicatedTop}:top:0", "{replicatedTop}:top:1", "{replicatedTop}:top:2", "cachedTop")
+	conn.Do("DEL", "cachedReader", "cachedLeaders", "cachedGhosts", "{cachedGhosts}:ghosts")
+	conn.Do("DEL", "goRedis", "goRedisString")
+	conn.Do("DEL", "batch", "batchOther", "batchGhosts", "{batchGhosts}:ghosts")
+	conn.Do("DEL", "intercepted")
+	conn.Do("DEL", "capped", "cappedLaps", "segmented", "{segmented}:meta")
+	conn.Do("DEL", "windowed", "{windowed}:daily:2013-05-01", "{windowed}:daily:2013-05-02", "{windowed}:weekly:2013-04-29", "{windowed}:monthly:2013-05")
+	conn.Do("DEL", "windowedExpiry", "{windowedExpiry}:daily:2013-05-01", "windowedErrors", "{windowedErrors}:daily:2013-05-01")
+	conn.Do("DEL", "interceptedCount", "{interceptedCount}:submissions", "{interceptedCount}:accounts", "{interceptedCount}:account:entries",
+		"{interceptedCount}:best", "{interceptedCount}:best:entries", "{interceptedCount}:pending", "{interceptedCount}:pending:scores", "{interceptedCount}:pending:queue",
+		"{interceptedCount}:oplog", "{interceptedCount}:writes")
+	conn.Do("DEL", "worldRecords", "{worldRecords}:pending", "{worldRecords}:pending:scores", "{worldRecords}:pending:queue")
+	conn.Do("DEL", "cappedRecords", "{cappedRecords}:pending", "{cappedRecords}:pending:scores", "{cappedRecords}:pending:queue")
+	conn.Do("DEL", "speedRun", "{speedRun}:pending", "{speedRun}:pending:scores", "{speedRun}:pending:queue")
+	conn.Do("DEL", "metadata", "{metadata}:meta", "filtered", "{filtered}:meta",
+		"filteredGhosts", "{filteredGhosts}:meta", "{filteredGhosts}:ghosts")
+	conn.Do("DEL", "migrated", "{migrated}:schema", "{customMigration}:schema")
+	for _, name := range []string{"entries", "removedEntries", "memberEntries", "cappedEntries"} {
+		tag := "{" + name + "}"
+		conn.Do("DEL", name, tag+":accounts", tag+":best", tag+":best:entries", tag+":account:entries")
+	}
+	for _, name := range []string{"ghosts", "unrankedGhosts", "sharedGhosts", "tiedGhosts", "cappedGhosts"} {
+		conn.Do("DEL", name, "{"+name+"}:ghosts")
+	}
+	for _, name := range []string{"replicatedMax", "replicatedSum", "replicatedLast", "replicatedRace"} {
+		tag := "{east:" + name + "}"
+		conn.Do("DEL", "east:"+name, tag+":oplog", tag+":writes", tag+":replicated:west")
+	}
+	conn.Do("DEL", "diffA", "diffB")
+	conn.Do("DEL", "statsLaps", "{statsLaps}:ghosts", "{statsLaps}:submissions", "statsKills", "statsRuns",
+		"statsGhosts", "{statsGhosts}:ghosts", "{statsGhosts}:submissions")
+	conn.Do("DEL", "game", "game:race", "game:drift", "game:race:desert", "game:race:city", "game:drift:docks")
+	conn.Do("DEL", "mergeKills", "{mergeKills}:meta", "{mergeKills}:history:new", "mergeLaps")
+	conn.Do("DEL", "mergeBestKills", "mergeBestLaps", "mergeExpiry", "{mergeExpiry}:history:new", "mergeGame", "mergeRace", "mergeDrift")
+	conn.Do("DEL", "daily", "{daily}:zones", "{daily}:days", "{daily}:day:2013-05-01", "{daily}:day:2013-05-02")
+	conn.Do("DEL", "{dailyCleanup}:days", "{dailyCleanup}:day:2013-05-03", "{dailyCleanup}:day:2013-05-04",
+		"{dailyCleanup}:day:2013-05-03:submissions", "{dailyCleanup}:day:2013-05-04:submissions")
+	for _, job := range []string{"once", "locked", "tick", "clockStreak:sweep"} {
+		conn.Do("DEL", "test:job:"+job+":fence", "test:job:"+job+":last", "test:job:"+job+":lock")
+	}
+	for _, streak := range []string{"dailyStreak", "localStreak", "staleStreak", "clockStreak", "goRedisStreak", "game:optionsStreak"} {
+		tag := "{" + streak + "}"
+		conn.Do("DEL", tag+":current", tag+":best", tag+":last")
+	}
 }
 
 func (s *S) TestRankMember(c *gocheck.C) {
@@ -127,3 +190,36 @@ func (s *S) TestGetUserByRank(c *gocheck.C) {
 	c.Assert(member.Name, gocheck.Equals, "member_91")
 	c.Assert(member.Rank, gocheck.Equals, 10)
 }
+
+func (s *S) TestGetLeadersReverse(c *gocheck.C) {
+	bestYear := NewLeaderboard(redisSettings, "worstYear", 25)
+	for i := 0; i < 30; i++ {
+		bestYear.RankMember("member_"+strconv.Itoa(i), 1234*i)
+	}
+	users := bestYear.GetLeadersReverse(1)
+	c.Assert(len(users), gocheck.Equals, bestYear.PageSize)
+	c.Assert(users[0].Name, gocheck.Equals, "member_0")
+	c.Assert(users[0].Rank, gocheck.Equals, 30)
+	c.Assert(users[24].Name, gocheck.Equals, "member_24")
+	c.Assert(users[24].Rank, gocheck.Equals, 6)
+	users = bestYear.GetLeadersReverse(2)
+	c.Assert(users[0].Name, gocheck.Equals, "member_25")
+	c.Assert(users[4].Name, gocheck.Equals, "member_29")
+	c.Assert(users[4].Rank, gocheck.Equals, 1)
+}
+
+func (s *S) TestBottom(c *gocheck.C) {
+	lapTime := NewLeaderboard(redisSettings, "lapTime", 25)
+	lapTime.Order = LowToHigh
+	for i := 0; i < 10; i++ {
+		lapTime.RankMember("member_"+strconv.Itoa(i), 100+i)
+	}
+	c.Assert(lapTime.GetRank("member_0"), gocheck.Equals, 1)
+	users := lapTime.Bottom(3)
+	c.Assert(len(users), gocheck.Equals, 3)
+	c.Assert(users[0].Name, gocheck.Equals, "member_9")
+	c.Assert(users[0].Rank, gocheck.Equals, 10)
+	c.Assert(users[2].Name, gocheck.Equals, "member_7")
+	c.Assert(users[2].Rank, gocheck.Equals, 8)
+	c.Assert(len(lapTime.Bottom(50)), gocheck.Equals, 10)
+}
@@ -0,0 +1,178 @@
+package leaderboard
+
+import (
+	"errors"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// MergePolicy tells how the scores of two merged members combine.
+type MergePolicy int
+
+const (
+	// MergeMax keeps the highest of the two scores.
+	MergeMax MergePolicy = iota
+	// MergeMin keeps the lowest of the two scores, e.g. for best times.
+	MergeMin
+	// MergeSum adds the two scores up.
+	MergeSum
+	// MergeKeepTarget keeps the score of the member merged into, taking the
+	// other score only when it has none.
+	MergeKeepTarget
+	// MergeBest keeps the better of the two scores by the sort order of each
+	// leaderboard: the highest on HighToLow, the lowest on LowToHigh.
+	MergeBest
+)
+
+// MergeOutcome is the result of a merge on a single leaderboard. Merged
+// tells whether the source member was ranked there; User is the target
+// member as ranked after the merge.
+type MergeOutcome struct {
+	Leaderboard string
+	Merged      bool
+	User        User
+	Err         error
+}
+
+/* End Structs model */
+
+// mergeMembersScript merges a member into another: it combines their scores
+// by the merge policy, MergeBest being resolved by the caller, fills the
+// metadata of the target with the fields it lacks, joins their rank
+// histories, keeping the later expiry unless one of them has none, and
+// removes the source. It returns 1 when the source was ranked.
+// KEYS: board, metadata, source history, target history, ghosts.
+// ARGV: source, target, merge policy.
+var mergeMembersScript = redis.NewScript(5, metadataLua+`
+local from, into = ARGV[1], ARGV[2]
+local fromScore = tonumber(redis.call('ZSCORE', KEYS[1], from))
+local fields = data(from)
+for field, value in pairs(data(into)) do
+	fields[field] = value
+end
+if next(fields) ~= nil then
+	redis.call('HSET', KEYS[2], into, cjson.encode(fields))
+end
+redis.call('HDEL', KEYS[2], from)
+if redis.call('EXISTS', KEYS[3]) == 1 then
+	local ttl = redis.call('PTTL', KEYS[3])
+	local intoTTL = redis.call('PTTL', KEYS[4])
+	if intoTTL == -1 or (intoTTL > ttl and ttl ~= -1) then
+		ttl = intoTTL
+	end
+	redis.call('ZUNIONSTORE', KEYS[4], 2, KEYS[4], KEYS[3], 'AGGREGATE', 'MAX')
+	redis.call('DEL', KEYS[3])
+	if ttl > 0 then
+		redis.call('PEXPIRE', KEYS[4], ttl)
+	else
+		redis.call('PERSIST', KEYS[4])
+	end
+end
+if not fromScore then
+	return 0
+end
+local score = fromScore
+local intoScore = tonumber(redis.call('ZSCORE', KEYS[1], into))
+if intoScore then
+	if ARGV[3] == '0' then
+		score = math.max(fromScore, intoScore)
+	elseif ARGV[3] == '1' then
+		score = math.min(fromScore, intoScore)
+	elseif ARGV[3] == '2' then
+		score = fromScore + intoScore
+	else
+		score = intoScore
+	end
+end
+redis.call('ZADD', KEYS[1], score, into)
+redis.call('ZREM', KEYS[1], from)
+redis.call('ZREM', KEYS[5], from)
+return 1
+`)
+
+/* Private functions */
+
+func (l *Leaderboard) mergeMembers(from string, into string, policy MergePolicy) MergeOutcome {
+	outcome := MergeOutcome{Leaderboard: l.Name, User: User{Name: into}}
+	if policy == MergeBest {
+		policy = MergeMax
+		if l.Order == LowToHigh {
+			policy = MergeMin
+		}
+	}
+	conn := l.conn()
+	defer conn.Close()
+	history := RankHistory{Leaderboard: *l}
+	merged, err := redis.Int(mergeMembersScript.Do(conn, l.key(), l.metaKey(), history.key(from), history.key(into), l.ghostsKey(),
+		from, into, int(policy)))
+	if err != nil {
+		outcome.Err = err
+		return outcome
+	}
+	outcome.Merged = merged == 1
+	outcome.User, err = l.getMember(conn, into)
+	if err != nil && err != redis.ErrNil {
+		outcome.Err = err
+	}
+	return outcome
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// MergeMembers merges the member from into the member into on every
+// registered leaderboard, e.g. when two accounts are linked. Scores combine
+// by policy, metadata fields of into win over the ones of from, rank
+// histories are joined and from is removed. Parent leaderboards then take
+// the scores of both members again from their children, by their
+// aggregation, rather than combining them by policy. It returns the outcome
+// on every leaderboard, in registration order, and the first error met.
+//
+// The merge writes the registered leaderboards only. It is not logged for
+// replication, so every deployment of a ReplicatedBoard must merge on its
+// own, and it does not move the entries and accounts of an Entries, nor the
+// days of a DailyBoard or the streaks of a StreakBoard.
+func (r *Registry) MergeMembers(from string, into string, policy MergePolicy) ([]MergeOutcome, error) {
+	if from == into {
+		return nil, errors.New("leaderboard: cannot merge a member into itself")
+	}
+	outcomes := make([]MergeOutcome, 0, len(r.names))
+	var first error
+	for _, board := range r.Boards() {
+		result, err := board.invoke("MergeMembers", []interface{}{from, into, policy}, func(args []interface{}) (interface{}, error) {
+			outcome := board.mergeMembers(args[0].(string), args[1].(string), args[2].(MergePolicy))
+			return outcome, outcome.Err
+		})
+		outcome, ok := result.(MergeOutcome)
+		if !ok {
+			outcome = MergeOutcome{Leaderboard: board.Name, User: User{Name: into}}
+		}
+		outcome.Err = err
+		if outcome.Err != nil && first == nil {
+			first = outcome.Err
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	// Children are registered after their parents, so walking the outcomes
+	// backwards aggregates every parent after its own children.
+	for i := len(outcomes) - 1; i >= 0; i-- {
+		name := outcomes[i].Leaderboard
+		if len(r.children[name]) == 0 || outcomes[i].Err != nil {
+			continue
+		}
+		if _, err := r.aggregate(name, from); err != nil && err != redis.ErrNil {
+			outcomes[i].Err = err
+		} else if outcomes[i].User, err = r.aggregate(name, into); err != nil && err != redis.ErrNil {
+			outcomes[i].Err = err
+		}
+		if outcomes[i].Err != nil && first == nil {
+			first = outcomes[i].Err
+		}
+	}
+	return outcomes, first
+}
+
+/* End Public functions */
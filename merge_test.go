@@ -0,0 +1,130 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestMergeMembers(c *gocheck.C) {
+	registry := NewRegistry()
+	kills := NewLeaderboard(redisSettings, "mergeKills", 10)
+	laps, _ := New(redisSettings, "mergeLaps", WithSortOrder(LowToHigh))
+	empty := NewLeaderboard(redisSettings, "mergeEmpty", 10)
+	registry.Register(kills)
+	registry.Register(laps)
+	registry.Register(empty)
+
+	kills.RankMember("old", 30)
+	kills.RankMember("new", 20)
+	kills.RankMember("felipe", 40)
+	kills.SetMemberData("old", map[string]string{"country": "BR", "platform": "pc"})
+	kills.SetMemberData("new", map[string]string{"platform": "console"})
+	history := NewRankHistory(kills, 0)
+	start := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+	history.Sample(start, "old")
+	history.Sample(start.Add(time.Hour), "new")
+	laps.RankMember("old", 61)
+
+	outcomes, err := registry.MergeMembers("old", "new", MergeSum)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(outcomes), gocheck.Equals, 3)
+	c.Assert(outcomes[0].Merged, gocheck.Equals, true)
+	c.Assert(outcomes[0].User.Score, gocheck.Equals, 50)
+	c.Assert(outcomes[0].User.Rank, gocheck.Equals, 1)
+	c.Assert(outcomes[1].Merged, gocheck.Equals, true)
+	c.Assert(outcomes[1].User.Score, gocheck.Equals, 61)
+	c.Assert(outcomes[2].Merged, gocheck.Equals, false)
+	c.Assert(outcomes[2].User.Rank, gocheck.Equals, 0)
+
+	c.Assert(kills.GetRank("old"), gocheck.Equals, 0)
+	c.Assert(kills.TotalMembers(), gocheck.Equals, 2)
+	data, _ := kills.GetMemberData("new")
+	c.Assert(data, gocheck.DeepEquals, map[string]string{"country": "BR", "platform": "console"})
+	data, _ = kills.GetMemberData("old")
+	c.Assert(len(data), gocheck.Equals, 0)
+	points, _ := history.Points("new", start, start.Add(time.Hour))
+	c.Assert(len(points), gocheck.Equals, 2)
+	points, _ = history.Points("old", start, start.Add(time.Hour))
+	c.Assert(len(points), gocheck.Equals, 0)
+
+	kills.RankMember("alt", 45)
+	outcomes, _ = registry.MergeMembers("alt", "felipe", MergeKeepTarget)
+	c.Assert(outcomes[0].User.Score, gocheck.Equals, 40)
+	kills.RankMember("alt", 45)
+	outcomes, _ = registry.MergeMembers("alt", "felipe", MergeMax)
+	c.Assert(outcomes[0].User.Score, gocheck.Equals, 45)
+	laps.RankMember("alt", 58)
+	outcomes, _ = registry.MergeMembers("alt", "new", MergeMin)
+	c.Assert(outcomes[1].User.Score, gocheck.Equals, 58)
+
+	_, err = registry.MergeMembers("new", "new", MergeMax)
+	c.Assert(err, gocheck.NotNil)
+}
+
+func (s *S) TestMergeBestFollowsSortOrder(c *gocheck.C) {
+	registry := NewRegistry()
+	kills := NewLeaderboard(redisSettings, "mergeBestKills", 10)
+	laps, _ := New(redisSettings, "mergeBestLaps", WithSortOrder(LowToHigh))
+	registry.Register(kills)
+	registry.Register(laps)
+	kills.RankMember("old", 30)
+	kills.RankMember("new", 20)
+	laps.RankMember("old", 58)
+	laps.RankMember("new", 61)
+
+	outcomes, err := registry.MergeMembers("old", "new", MergeBest)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(outcomes[0].User.Score, gocheck.Equals, 30)
+	c.Assert(outcomes[1].User.Score, gocheck.Equals, 58)
+}
+
+func (s *S) TestMergeMembersKeepsHistoryExpiry(c *gocheck.C) {
+	registry := NewRegistry()
+	kills := NewLeaderboard(redisSettings, "mergeExpiry", 10)
+	registry.Register(kills)
+	kills.RankMember("old", 30)
+	kills.RankMember("new", 20)
+	history := NewRankHistory(kills, time.Hour)
+	start := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+	history.Sample(start, "old")
+	history.Sample(start, "new")
+
+	_, err := registry.MergeMembers("old", "new", MergeBest)
+	c.Assert(err, gocheck.IsNil)
+	conn := kills.conn()
+	defer conn.Close()
+	ttl, _ := redis.Int(conn.Do("TTL", history.key("new")))
+	c.Assert(ttl > 0 && ttl <= 3601, gocheck.Equals, true)
+
+	kills.RankMember("old", 40)
+	persistent := NewRankHistory(kills, 0)
+	persistent.Sample(start, "old")
+	_, err = registry.MergeMembers("old", "new", MergeBest)
+	c.Assert(err, gocheck.IsNil)
+	ttl, _ = redis.Int(conn.Do("TTL", history.key("new")))
+	c.Assert(ttl, gocheck.Equals, -1)
+}
+
+func (s *S) TestMergeMembersReaggregatesParents(c *gocheck.C) {
+	registry := NewRegistry()
+	game, _ := New(redisSettings, "mergeGame", WithAggregation(AggregateSum))
+	race := NewLeaderboard(redisSettings, "mergeRace", 10)
+	drift := NewLeaderboard(redisSettings, "mergeDrift", 10)
+	registry.Register(game)
+	registry.RegisterChild("mergeGame", race)
+	registry.RegisterChild("mergeGame", drift)
+	registry.RankMember("mergeRace", "old", 10)
+	registry.RankMember("mergeRace", "new", 2)
+	registry.RankMember("mergeDrift", "new", 15)
+
+	outcomes, err := registry.MergeMembers("old", "new", MergeMax)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(outcomes[0].User.Score, gocheck.Equals, 25)
+	c.Assert(outcomes[1].User.Score, gocheck.Equals, 10)
+	user, err := game.GetMember("new")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Score, gocheck.Equals, 25)
+	c.Assert(game.TotalMembers(), gocheck.Equals, 1)
+}
@@ -0,0 +1,275 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Filter selects members by metadata. A member matches when, for every
+// field, its value is one of the listed values.
+type Filter map[string][]string
+
+// FilteredUser is a member matching a filter. Rank is its rank on the whole
+// leaderboard, FilteredRank its position among the matching members.
+type FilteredUser struct {
+	User
+	FilteredRank int
+}
+
+/* End Structs model */
+
+// filterBatchSize is how many members a single filter script call checks.
+const filterBatchSize = 500
+
+// metadataLua defines data(member), returning the metadata of a member as a
+// table.
+// KEYS: board, metadata.
+const metadataLua = `
+local function data(member)
+	local current = redis.call('HGET', KEYS[2], member)
+	if current then
+		return cjson.decode(current)
+	end
+	return {}
+end
+`
+
+// getDataScript returns the metadata of a member as a JSON object.
+// ARGV: member.
+var getDataScript = redis.NewScript(2, metadataLua+`
+return cjson.encode(data(ARGV[1]))
+`)
+
+// setDataScript stores metadata fields of a member, keeping the others.
+// ARGV: member, then field and value pairs.
+var setDataScript = redis.NewScript(2, metadataLua+`
+local fields = data(ARGV[1])
+for i = 2, #ARGV, 2 do
+	fields[ARGV[i]] = ARGV[i + 1]
+end
+redis.call('HSET', KEYS[2], ARGV[1], cjson.encode(fields))
+`)
+
+// filterScript walks a batch of the leaderboard in rank order and returns how
+// many members it checked followed by the member, score, offset and ghost
+// flag of every match.
+// KEYS: board, metadata, ghosts.
+// ARGV: first offset, batch size, sort order, filter, ghost policy.
+var filterScript = redis.NewScript(3, metadataLua+`
+local command = 'ZREVRANGE'
+if ARGV[3] == '1' then command = 'ZRANGE' end
+local start = tonumber(ARGV[1])
+local values = redis.call(command, KEYS[1], start, start + tonumber(ARGV[2]) - 1, 'WITHSCORES')
+local filter = cjson.decode(ARGV[4])
+local result = {#values / 2}
+for i = 1, #values, 2 do
+	local fields = data(values[i])
+	local matches = true
+	for field, allowed in pairs(filter) do
+		local found = false
+		for _, candidate in ipairs(allowed) do
+			if fields[field] == candidate then
+				found = true
+				break
+			end
+		end
+		if not found then
+			matches = false
+			break
+		end
+	end
+	if matches then
+		table.insert(result, values[i])
+		table.insert(result, values[i + 1])
+		table.insert(result, start + (i - 1) / 2)
+		local ghost = 0
+		if ARGV[5] ~= '0' and redis.call('ZSCORE', KEYS[3], values[i]) then
+			ghost = 1
+		end
+		table.insert(result, ghost)
+	end
+end
+return result
+`)
+
+/* Private functions */
+
+// metaKey is the hash holding the metadata of every member as JSON objects.
+func (l *Leaderboard) metaKey() string {
+	return l.subKey(":meta")
+}
+
+func (l *Leaderboard) metadataArgs() []interface{} {
+	return []interface{}{l.key(), l.metaKey()}
+}
+
+func (l *Leaderboard) getMemberData(member string) (map[string]string, error) {
+	conn := l.conn()
+	defer conn.Close()
+	reply, err := redis.Bytes(getDataScript.Do(conn, append(l.metadataArgs(), member)...))
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]string{}
+	err = json.Unmarshal(reply, &data)
+	return data, err
+}
+
+func (l *Leaderboard) filterRange(filter Filter, offset int, count int) ([]FilteredUser, error) {
+	if filter == nil {
+		filter = Filter{}
+	}
+	for field, values := range filter {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("leaderboard: filter field %q has no values", field)
+		}
+	}
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	conn := l.conn()
+	defer conn.Close()
+	users := []FilteredUser{}
+	matched, players := 0, 0
+	for start := 0; len(users) < count; start += filterBatchSize {
+		values, err := redis.Values(filterScript.Do(conn, l.key(), l.metaKey(), l.ghostsKey(), start, filterBatchSize, int(l.Order), data, int(l.ghosts)))
+		if err != nil {
+			return nil, err
+		}
+		var checked int
+		if values, err = redis.Scan(values, &checked); err != nil {
+			return nil, err
+		}
+		for len(values) > 0 && len(users) < count {
+			user := FilteredUser{}
+			var position, ghost int
+			if values, err = redis.Scan(values, &user.Name, &user.Score, &position, &ghost); err != nil {
+				return nil, err
+			}
+			unranked := ghost == 1 && l.ghosts == GhostsUnranked
+			matched++
+			if !unranked {
+				players++
+			}
+			if matched <= offset {
+				continue
+			}
+			user.Rank = position + 1
+			if !unranked {
+				user.FilteredRank = players
+			}
+			users = append(users, user)
+		}
+		if checked < filterBatchSize {
+			break
+		}
+	}
+	if l.ties == TieShared || l.ghosts != NoGhosts {
+		ranked := make([]User, len(users))
+		for i := range users {
+			ranked[i] = users[i].User
+		}
+		if err := l.rankUsers(connRanks{l, conn}, ranked); err != nil {
+			return nil, err
+		}
+		for i := range users {
+			users[i].User = ranked[i]
+		}
+	}
+	return users, nil
+}
+
+func (l *Leaderboard) getFilteredLeaders(filter Filter, page int) ([]FilteredUser, error) {
+	if page < 1 {
+		page = 1
+	}
+	return l.filterRange(filter, (page-1)*l.PageSize, l.PageSize)
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// SetMemberData stores metadata fields of a member, e.g. its country or
+// platform, keeping the fields not given. The metadata is removed along with
+// the member.
+func (l *Leaderboard) SetMemberData(member string, data map[string]string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	_, err := l.invoke("SetMemberData", []interface{}{member, data}, func(args []interface{}) (interface{}, error) {
+		conn := l.conn()
+		defer conn.Close()
+		return setDataScript.Do(conn, redis.Args(l.metadataArgs()).Add(args[0]).AddFlat(args[1])...)
+	})
+	return err
+}
+
+// GetMemberData returns the metadata fields of a member.
+func (l *Leaderboard) GetMemberData(member string) (map[string]string, error) {
+	result, err := l.invoke("GetMemberData", []interface{}{member}, func(args []interface{}) (interface{}, error) {
+		return l.getMemberData(args[0].(string))
+	})
+	data, _ := result.(map[string]string)
+	return data, err
+}
+
+// FilterRange returns count members matching filter in rank order, skipping
+// the first offset matches. The leaderboard is walked from the top in
+// batches, the filter being applied by Redis. Every field of filter must
+// list at least one value. Ghosts left unranked match with a zero
+// FilteredRank and are not counted in the filtered ranks of players.
+func (l *Leaderboard) FilterRange(filter Filter, offset int, count int) ([]FilteredUser, error) {
+	result, err := l.invoke("FilterRange", []interface{}{filter, offset, count}, func(args []interface{}) (interface{}, error) {
+		return l.filterRange(args[0].(Filter), args[1].(int), args[2].(int))
+	})
+	users, _ := result.([]FilteredUser)
+	return users, err
+}
+
+// WithSegment names a filter of the leaderboard, e.g. the players of a
+// region, to be read with GetSegmentLeaders.
+func WithSegment(name string, filter Filter) Option {
+	return func(l *Leaderboard) {
+		if l.segments == nil {
+			l.segments = map[string]Filter{}
+		}
+		l.segments[name] = filter
+	}
+}
+
+// Segment returns the filter of the segment name.
+func (l *Leaderboard) Segment(name string) (Filter, bool) {
+	filter, ok := l.segments[name]
+	return filter, ok
+}
+
+// GetSegmentLeaders returns a page of the members of the segment name.
+func (l *Leaderboard) GetSegmentLeaders(name string, page int) ([]FilteredUser, error) {
+	result, err := l.invoke("GetSegmentLeaders", []interface{}{name, page}, func(args []interface{}) (interface{}, error) {
+		filter, ok := l.segments[args[0].(string)]
+		if !ok {
+			return nil, fmt.Errorf("leaderboard: unknown segment %q", args[0])
+		}
+		return l.getFilteredLeaders(filter, args[1].(int))
+	})
+	users, _ := result.([]FilteredUser)
+	return users, err
+}
+
+// GetFilteredLeaders returns a page of the members matching filter. Unlike
+// GetLeaders, the page holds only the members found.
+func (l *Leaderboard) GetFilteredLeaders(filter Filter, page int) ([]FilteredUser, error) {
+	result, err := l.invoke("GetFilteredLeaders", []interface{}{filter, page}, func(args []interface{}) (interface{}, error) {
+		return l.getFilteredLeaders(args[0].(Filter), args[1].(int))
+	})
+	users, _ := result.([]FilteredUser)
+	return users, err
+}
+
+/* End Public functions */
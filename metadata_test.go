@@ -0,0 +1,91 @@
+package leaderboard
+
+import (
+	"strconv"
+
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestMemberData(c *gocheck.C) {
+	board := NewLeaderboard(redisSettings, "metadata", 10)
+	board.RankMember("dayvson", 10)
+	c.Assert(board.SetMemberData("dayvson", map[string]string{"country": "BR", "platform": "pc"}), gocheck.IsNil)
+	c.Assert(board.SetMemberData("dayvson", map[string]string{"platform": "console"}), gocheck.IsNil)
+	data, err := board.GetMemberData("dayvson")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(data, gocheck.DeepEquals, map[string]string{"country": "BR", "platform": "console"})
+
+	board.RemoveMember("dayvson")
+	data, _ = board.GetMemberData("dayvson")
+	c.Assert(len(data), gocheck.Equals, 0)
+}
+
+func (s *S) TestFilterRange(c *gocheck.C) {
+	board := NewLeaderboard(redisSettings, "filtered", 3)
+	platforms := []string{"pc", "console", "mobile"}
+	for i := 0; i < 1200; i++ {
+		name := "member_" + strconv.Itoa(i)
+		board.RankMember(name, i)
+		board.SetMemberData(name, map[string]string{"platform": platforms[i%3], "country": "BR"})
+	}
+
+	users, err := board.GetFilteredLeaders(Filter{"platform": {"pc"}}, 1)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(users), gocheck.Equals, 3)
+	c.Assert(users[0].Name, gocheck.Equals, "member_1197")
+	c.Assert(users[0].Rank, gocheck.Equals, 3)
+	c.Assert(users[0].FilteredRank, gocheck.Equals, 1)
+	c.Assert(users[2].Name, gocheck.Equals, "member_1191")
+
+	users, _ = board.FilterRange(Filter{"platform": {"mobile", "console"}, "country": {"BR"}}, 790, 20)
+	c.Assert(len(users), gocheck.Equals, 10)
+	c.Assert(users[0].FilteredRank, gocheck.Equals, 791)
+	c.Assert(users[9].Name, gocheck.Equals, "member_1")
+	c.Assert(users[9].Rank, gocheck.Equals, 1199)
+
+	users, _ = board.FilterRange(Filter{"country": {"US"}}, 0, 10)
+	c.Assert(len(users), gocheck.Equals, 0)
+	users, _ = board.FilterRange(nil, 0, 2)
+	c.Assert(users[1].FilteredRank, gocheck.Equals, 2)
+	_, err = board.FilterRange(Filter{"country": nil}, 0, 2)
+	c.Assert(err, gocheck.ErrorMatches, `leaderboard: filter field "country" has no values`)
+}
+
+func (s *S) TestFilterRangeGhosts(c *gocheck.C) {
+	board, _ := New(redisSettings, "filteredGhosts", WithGhostPolicy(GhostsUnranked))
+	board.AddGhost("developer", 100)
+	board.RankMember("dayvson", 80)
+	board.RankMember("felipe", 120)
+	for _, member := range []string{"developer", "dayvson", "felipe"} {
+		board.SetMemberData(member, map[string]string{"country": "BR"})
+	}
+
+	users, err := board.FilterRange(Filter{"country": {"BR"}}, 0, 10)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(users), gocheck.Equals, 3)
+	c.Assert(users[1].Name, gocheck.Equals, "developer")
+	c.Assert(users[1].Ghost, gocheck.Equals, true)
+	c.Assert(users[1].FilteredRank, gocheck.Equals, 0)
+	c.Assert(users[2].Rank, gocheck.Equals, 2)
+	c.Assert(users[2].FilteredRank, gocheck.Equals, 2)
+	users, _ = board.FilterRange(Filter{"country": {"BR"}}, 2, 10)
+	c.Assert(users[0].FilteredRank, gocheck.Equals, 2)
+}
+
+func (s *S) TestSegments(c *gocheck.C) {
+	board, _ := New(redisSettings, "segmented", WithSegment("brazil", Filter{"country": {"BR"}}))
+	board.RankMember("dayvson", 10)
+	board.RankMember("john", 20)
+	board.SetMemberData("dayvson", map[string]string{"country": "BR"})
+	board.SetMemberData("john", map[string]string{"country": "US"})
+
+	users, err := board.GetSegmentLeaders("brazil", 1)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(users), gocheck.Equals, 1)
+	c.Assert(users[0].Name, gocheck.Equals, "dayvson")
+	c.Assert(users[0].Rank, gocheck.Equals, 2)
+	_, err = board.GetSegmentLeaders("europe", 1)
+	c.Assert(err, gocheck.ErrorMatches, `leaderboard: unknown segment "europe"`)
+	_, err = New(redisSettings, "segmented", WithSegment("empty", Filter{"country": {}}))
+	c.Assert(err, gocheck.ErrorMatches, `leaderboard: field "country" of segment "empty" has no values`)
+}
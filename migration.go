@@ -0,0 +1,141 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Migration upgrades the Redis layout of a leaderboard to Version. Step
+// migrates a batch of about count items starting at cursor, "" for the
+// first batch, and returns the cursor of the next batch or "" when done.
+// Steps run while the leaderboard is in use, so the leaderboard must stay
+// readable between two batches.
+type Migration struct {
+	Version int
+	Name    string
+	Step    func(l *Leaderboard, conn redis.Conn, cursor string, count int) (string, error)
+}
+
+// Migrator runs the migrations of a leaderboard in version order, a batch at
+// a time. Migrations must be sorted by strictly increasing version. Only one
+// migrator may run on a leaderboard at once: schedule it with MigrateJob to
+// have the scheduler lock it.
+type Migrator struct {
+	Migrations []Migration
+	BatchSize  int
+}
+
+/* End Structs model */
+
+// SchemaVersion is the layout version the library writes. The layout has
+// not changed since the first release, so the library has no migration of
+// its own yet.
+const SchemaVersion = 0
+
+/* Private functions */
+
+// schemaKey is the hash holding the layout version of the leaderboard and the
+// progress of the running migration.
+func (l *Leaderboard) schemaKey() string {
+	return l.subKey(":schema")
+}
+
+func (l *Leaderboard) version() (int, error) {
+	conn := l.conn()
+	defer conn.Close()
+	version, err := redis.Int(conn.Do("HGET", l.schemaKey(), "version"))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+	return version, err
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// EscapePattern escapes the glob characters of a key, for the key to match
+// only itself in a SCAN MATCH or PSUBSCRIBE pattern.
+func EscapePattern(key string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(key)
+}
+
+// NewMigrator returns a migrator running the migrations of the library in
+// batches of batchSize, 100 when below one. Migrations of the caller may be
+// appended with versions above SchemaVersion.
+func NewMigrator(batchSize int) Migrator {
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	return Migrator{BatchSize: batchSize}
+}
+
+// Version returns the layout version of a leaderboard, 0 for leaderboards
+// never migrated.
+func (l *Leaderboard) Version() (int, error) {
+	result, err := l.invoke("Version", nil, func(args []interface{}) (interface{}, error) {
+		return l.version()
+	})
+	version, _ := result.(int)
+	return version, err
+}
+
+// Step migrates one batch of the first migration l has not gone through and
+// returns whether l is up to date.
+func (m *Migrator) Step(l *Leaderboard) (bool, error) {
+	conn := l.conn()
+	defer conn.Close()
+	values, err := redis.Values(conn.Do("HMGET", l.schemaKey(), "version", "cursor"))
+	if err != nil {
+		return false, err
+	}
+	var version int
+	var cursor string
+	if _, err := redis.Scan(values, &version, &cursor); err != nil {
+		return false, err
+	}
+	for i := 1; i < len(m.Migrations); i++ {
+		if m.Migrations[i].Version <= m.Migrations[i-1].Version {
+			return false, fmt.Errorf("leaderboard: migration versions must increase, got %d after %d", m.Migrations[i].Version, m.Migrations[i-1].Version)
+		}
+	}
+	for _, migration := range m.Migrations {
+		if migration.Version <= version {
+			continue
+		}
+		next, err := migration.Step(l, conn, cursor, m.BatchSize)
+		if err != nil {
+			return false, fmt.Errorf("leaderboard: migration %d (%s) of %s: %v", migration.Version, migration.Name, l.Name, err)
+		}
+		if next != "" {
+			_, err = conn.Do("HSET", l.schemaKey(), "cursor", next)
+			return false, err
+		}
+		conn.Send("HDEL", l.schemaKey(), "cursor")
+		_, err = conn.Do("HSET", l.schemaKey(), "version", strconv.Itoa(migration.Version))
+		return migration.Version == m.Migrations[len(m.Migrations)-1].Version, err
+	}
+	return true, nil
+}
+
+// Migrate runs the pending migrations of l until it is up to date or ctx is
+// done.
+func (m *Migrator) Migrate(ctx context.Context, l *Leaderboard) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		done, err := m.Step(l)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+/* End Public functions */
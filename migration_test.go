@@ -0,0 +1,104 @@
+package leaderboard
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+// lowercaseMembers is a migration folding member names to lower case, the
+// names folded together keeping their best score. Members already folded are
+// left as they are, so a batch run twice does no harm.
+func lowercaseMembers(l *Leaderboard, conn redis.Conn, cursor string, count int) (string, error) {
+	if cursor == "" {
+		cursor = "0"
+	}
+	values, err := redis.Values(conn.Do("ZSCAN", l.key(), cursor, "COUNT", count))
+	if err != nil {
+		return cursor, err
+	}
+	var members []string
+	if _, err := redis.Scan(values, &cursor, &members); err != nil {
+		return cursor, err
+	}
+	comparison := "GT"
+	if l.Order == LowToHigh {
+		comparison = "LT"
+	}
+	for i := 0; i < len(members); i += 2 {
+		name := members[i]
+		if lower := strings.ToLower(name); lower != name {
+			conn.Send("ZADD", l.key(), comparison, members[i+1], lower)
+			conn.Send("ZREM", l.key(), name)
+		}
+	}
+	if _, err := conn.Do(""); err != nil {
+		return cursor, err
+	}
+	if cursor == "0" {
+		return "", nil
+	}
+	return cursor, nil
+}
+
+func (s *S) TestMigrateLowercaseMembers(c *gocheck.C) {
+	board := NewLeaderboard(redisSettings, "migrated", 10)
+	for i := 0; i < 25; i++ {
+		board.RankMember("Member_"+strconv.Itoa(i), i)
+	}
+	board.RankMember("member_3", 10)
+	board.RankMember("member_20", 10)
+	version, err := board.Version()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(version, gocheck.Equals, SchemaVersion)
+
+	migrator := NewMigrator(10)
+	migrator.Migrations = append(migrator.Migrations, Migration{Version: SchemaVersion + 1, Name: "lowercase members", Step: lowercaseMembers})
+	c.Assert(migrator.Migrate(context.Background(), &board), gocheck.IsNil)
+	version, _ = board.Version()
+	c.Assert(version, gocheck.Equals, SchemaVersion+1)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 25)
+	c.Assert(board.GetRank("Member_7"), gocheck.Equals, 0)
+	user, _ := board.GetMember("member_3")
+	c.Assert(user.Score, gocheck.Equals, 10)
+	user, _ = board.GetMember("member_20")
+	c.Assert(user.Score, gocheck.Equals, 20)
+
+	done, err := migrator.Step(&board)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(done, gocheck.Equals, true)
+}
+
+func (s *S) TestMigrateCustomStep(c *gocheck.C) {
+	board := NewLeaderboard(redisSettings, "customMigration", 10)
+	migrator := NewMigrator(0)
+	calls := 0
+	migrator.Migrations = append(migrator.Migrations, Migration{Version: SchemaVersion + 1, Name: "custom", Step: func(l *Leaderboard, conn redis.Conn, cursor string, count int) (string, error) {
+		calls++
+		if cursor == "" {
+			return "1", nil
+		}
+		c.Assert(cursor, gocheck.Equals, "1")
+		return "", nil
+	}})
+	c.Assert(migrator.Migrate(context.Background(), &board), gocheck.IsNil)
+	c.Assert(calls, gocheck.Equals, 2)
+	version, _ := board.Version()
+	c.Assert(version, gocheck.Equals, SchemaVersion+1)
+}
+
+func (s *S) TestMigratorRequiresIncreasingVersions(c *gocheck.C) {
+	board := NewLeaderboard(redisSettings, "unorderedMigration", 10)
+	migrator := NewMigrator(0)
+	skip := func(l *Leaderboard, conn redis.Conn, cursor string, count int) (string, error) {
+		return "", nil
+	}
+	migrator.Migrations = append(migrator.Migrations, Migration{Version: 3, Step: skip}, Migration{Version: 2, Step: skip})
+	_, err := migrator.Step(&board)
+	c.Assert(err, gocheck.ErrorMatches, "leaderboard: migration versions must increase, got 2 after 3")
+	version, _ := board.Version()
+	c.Assert(version, gocheck.Equals, 0)
+}
@@ -0,0 +1,250 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Moderation holds the submissions that would land in the first Ranks places
+// of a leaderboard in a review queue, until a moderator approves them into
+// the leaderboard or rejects them. Other submissions are ranked right away.
+type Moderation struct {
+	Leaderboard Leaderboard
+	Ranks       int
+}
+
+// Submission is a score waiting for review. Proof references whatever backs
+// the score up, e.g. the URL of a replay. Rank is the rank the score would
+// have taken when it was submitted.
+type Submission struct {
+	Member      string    `json:"member"`
+	Score       int       `json:"score"`
+	Proof       string    `json:"proof"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	Rank        int       `json:"rank"`
+}
+
+/* End Structs model */
+
+// submitScript holds a score for review when it would land in the guarded
+// ranks. It returns the rank the score would take, and 1 when it is held.
+// The score is kept apart from the submission, as cjson rounds numbers.
+// KEYS: board, pending submissions, pending scores, review queue.
+// ARGV: member, score, sort order, guarded ranks, submission, submitted at.
+var submitScript = redis.NewScript(4, `
+local better
+if ARGV[3] == '1' then
+	better = redis.call('ZCOUNT', KEYS[1], '-inf', '(' .. ARGV[2])
+else
+	better = redis.call('ZCOUNT', KEYS[1], '(' .. ARGV[2], '+inf')
+end
+local current = tonumber(redis.call('ZSCORE', KEYS[1], ARGV[1]))
+local score = tonumber(ARGV[2])
+if current and ((ARGV[3] == '1' and current < score) or (ARGV[3] ~= '1' and current > score)) then
+	better = better - 1
+end
+local rank = better + 1
+if rank > tonumber(ARGV[4]) then
+	return {rank, 0}
+end
+local submission = cjson.decode(ARGV[5])
+submission['rank'] = rank
+submission['score'] = nil
+redis.call('HSET', KEYS[2], ARGV[1], cjson.encode(submission))
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[4], ARGV[6], ARGV[1])
+return {rank, 1}
+`)
+
+// takeScript removes a held submission from the review queue and returns it
+// with its score.
+// KEYS: pending submissions, pending scores, review queue. ARGV: member.
+var takeScript = redis.NewScript(3, `
+local submission = redis.call('HGET', KEYS[1], ARGV[1])
+if not submission then
+	return false
+end
+local score = redis.call('HGET', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+return {submission, score}
+`)
+
+/* Private functions */
+
+func (m *Moderation) pendingKey() string {
+	return m.Leaderboard.subKey(":pending")
+}
+
+func (m *Moderation) scoresKey() string {
+	return m.Leaderboard.subKey(":pending:scores")
+}
+
+func (m *Moderation) queueKey() string {
+	return m.Leaderboard.subKey(":pending:queue")
+}
+
+// decodeSubmission reads a held submission and its score.
+func decodeSubmission(data interface{}, score interface{}) (Submission, error) {
+	submission := Submission{}
+	bytes, err := redis.Bytes(data, nil)
+	if err != nil {
+		return submission, err
+	}
+	if err = json.Unmarshal(bytes, &submission); err != nil {
+		return submission, err
+	}
+	submission.Score, err = redis.Int(score, nil)
+	return submission, err
+}
+
+func decodeTaken(reply interface{}, err error) (Submission, error) {
+	values, err := redis.Values(reply, err)
+	if err != nil {
+		return Submission{}, err
+	}
+	if len(values) != 2 {
+		return Submission{}, fmt.Errorf("leaderboard: unexpected submission reply of %d values", len(values))
+	}
+	return decodeSubmission(values[0], values[1])
+}
+
+// rankSubmission applies an accepted score the way RankMember does.
+func (m *Moderation) rankSubmission(conn redis.Conn, member string, score int) error {
+	m.Leaderboard.sendScore(conn, member, score)
+	_, err := doPipeline(conn)
+	return err
+}
+
+func (m *Moderation) submit(member string, score int, proof string) (Submission, bool, error) {
+	submission := Submission{Member: member, Score: score, Proof: proof, SubmittedAt: m.Leaderboard.now()}
+	data, err := json.Marshal(submission)
+	if err != nil {
+		return submission, false, err
+	}
+	conn := m.Leaderboard.conn()
+	defer conn.Close()
+	values, err := redis.Ints(submitScript.Do(conn, m.Leaderboard.key(), m.pendingKey(), m.scoresKey(), m.queueKey(),
+		member, score, int(m.Leaderboard.Order), m.Ranks, data, submission.SubmittedAt.UnixNano()))
+	if err != nil {
+		return submission, false, err
+	}
+	submission.Rank = values[0]
+	if values[1] == 1 {
+		return submission, true, nil
+	}
+	return submission, false, m.rankSubmission(conn, member, score)
+}
+
+func (m *Moderation) approve(member string) (User, error) {
+	conn := m.Leaderboard.conn()
+	defer conn.Close()
+	submission, err := decodeTaken(takeScript.Do(conn, m.pendingKey(), m.scoresKey(), m.queueKey(), member))
+	if err != nil {
+		return User{Name: member}, err
+	}
+	if err := m.rankSubmission(conn, member, submission.Score); err != nil {
+		return User{Name: member}, err
+	}
+	return m.Leaderboard.getMember(conn, member)
+}
+
+func (m *Moderation) reject(member string) (Submission, error) {
+	conn := m.Leaderboard.conn()
+	defer conn.Close()
+	return decodeTaken(takeScript.Do(conn, m.pendingKey(), m.scoresKey(), m.queueKey(), member))
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+func NewModeration(l Leaderboard, ranks int) Moderation {
+	return Moderation{Leaderboard: l, Ranks: ranks}
+}
+
+// Submit ranks a score, or holds it for review when it would land in the
+// first Ranks places. It reports whether the submission is held. A new
+// submission of a held member replaces the previous one.
+func (m *Moderation) Submit(member string, score int, proof string) (Submission, bool, error) {
+	held := false
+	result, err := m.Leaderboard.invoke("Moderation.Submit", []interface{}{member, score, proof}, func(args []interface{}) (interface{}, error) {
+		submission, h, err := m.submit(args[0].(string), args[1].(int), args[2].(string))
+		held = h
+		return submission, err
+	})
+	submission, _ := result.(Submission)
+	return submission, held, err
+}
+
+// Pending returns held submissions, oldest first, starting at offset.
+func (m *Moderation) Pending(offset int, count int) ([]Submission, error) {
+	conn := m.Leaderboard.conn()
+	defer conn.Close()
+	members, err := redis.Values(conn.Do("ZRANGE", m.queueKey(), offset, offset+count-1))
+	if err != nil || len(members) == 0 {
+		return []Submission{}, err
+	}
+	conn.Send("HMGET", redis.Args{}.Add(m.pendingKey()).Add(members...)...)
+	conn.Send("HMGET", redis.Args{}.Add(m.scoresKey()).Add(members...)...)
+	replies, err := doPipeline(conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(replies) != 2 {
+		return nil, fmt.Errorf("leaderboard: unexpected reply for %d pending submissions", len(members))
+	}
+	values, _ := redis.Values(replies[0], nil)
+	scores, _ := redis.Values(replies[1], nil)
+	if len(values) != len(members) || len(scores) != len(members) {
+		return nil, fmt.Errorf("leaderboard: unexpected reply for %d pending submissions", len(members))
+	}
+	submissions := make([]Submission, 0, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		submission, err := decodeSubmission(value, scores[i])
+		if err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, submission)
+	}
+	return submissions, nil
+}
+
+// TotalPending returns how many submissions are held.
+func (m *Moderation) TotalPending() (int, error) {
+	conn := m.Leaderboard.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do("ZCARD", m.queueKey()))
+}
+
+// Approve moves the held submission of member into the leaderboard and
+// returns the member as now ranked. It returns redis.ErrNil when nothing is
+// held for member.
+func (m *Moderation) Approve(member string) (User, error) {
+	result, err := m.Leaderboard.invoke("Moderation.Approve", []interface{}{member}, func(args []interface{}) (interface{}, error) {
+		return m.approve(args[0].(string))
+	})
+	user, _ := result.(User)
+	return user, err
+}
+
+// Reject discards the held submission of member and returns it. It returns
+// redis.ErrNil when nothing is held for member.
+func (m *Moderation) Reject(member string) (Submission, error) {
+	result, err := m.Leaderboard.invoke("Moderation.Reject", []interface{}{member}, func(args []interface{}) (interface{}, error) {
+		return m.reject(args[0].(string))
+	})
+	submission, _ := result.(Submission)
+	return submission, err
+}
+
+/* End Public functions */
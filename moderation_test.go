@@ -0,0 +1,90 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestModerationSubmit(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC))
+	board, _ := New(redisSettings, "worldRecords", WithClock(clock))
+	moderation := NewModeration(board, 2)
+	_, pending, err := moderation.Submit("dayvson", 10, "")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(pending, gocheck.Equals, true)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 0)
+	moderation.Approve("dayvson")
+	c.Assert(board.TotalMembers(), gocheck.Equals, 1)
+
+	board.RankMember("bruno", 8)
+	submission, pending, err := moderation.Submit("maxwell", 5, "")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(pending, gocheck.Equals, false)
+	c.Assert(submission.Rank, gocheck.Equals, 3)
+
+	clock.Advance(time.Minute)
+	submission, pending, _ = moderation.Submit("maxwell", 50, "https://example.com/replay/1")
+	c.Assert(pending, gocheck.Equals, true)
+	c.Assert(submission.Rank, gocheck.Equals, 1)
+	c.Assert(board.GetRank("maxwell"), gocheck.Equals, 3)
+
+	submission, pending, _ = moderation.Submit("bruno", 4, "")
+	c.Assert(pending, gocheck.Equals, false)
+	c.Assert(submission.Rank, gocheck.Equals, 3)
+}
+
+func (s *S) TestModerationReview(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC))
+	board, _ := New(redisSettings, "speedRun", WithClock(clock), WithSortOrder(LowToHigh))
+	moderation := NewModeration(board, 3)
+	board.RankMember("dayvson", 100)
+	moderation.Submit("arthur", 90, "replay-1")
+	clock.Advance(time.Second)
+	moderation.Submit("felipe", 80, "replay-2")
+
+	total, _ := moderation.TotalPending()
+	c.Assert(total, gocheck.Equals, 2)
+	submissions, err := moderation.Pending(0, 10)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(len(submissions), gocheck.Equals, 2)
+	c.Assert(submissions[0].Member, gocheck.Equals, "arthur")
+	c.Assert(submissions[0].Proof, gocheck.Equals, "replay-1")
+	c.Assert(submissions[1].SubmittedAt.Equal(clock.Now()), gocheck.Equals, true)
+
+	user, err := moderation.Approve("felipe")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Rank, gocheck.Equals, 1)
+	c.Assert(user.Score, gocheck.Equals, 80)
+	submission, err := moderation.Reject("arthur")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(submission.Score, gocheck.Equals, 90)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 2)
+	total, _ = moderation.TotalPending()
+	c.Assert(total, gocheck.Equals, 0)
+
+	_, err = moderation.Approve("arthur")
+	c.Assert(err, gocheck.Equals, redis.ErrNil)
+	_, err = moderation.Reject("arthur")
+	c.Assert(err, gocheck.Equals, redis.ErrNil)
+}
+
+func (s *S) TestModerationApproveRanksMember(c *gocheck.C) {
+	board, _ := New(redisSettings, "cappedRecords", WithCap(2))
+	moderation := NewModeration(board, 1)
+	board.RankMember("dayvson", 10)
+	board.RankMember("felipe", 5)
+	score := 1<<53 + 1
+	_, held, _ := moderation.Submit("arthur", score, "")
+	c.Assert(held, gocheck.Equals, true)
+	submissions, err := moderation.Pending(0, 1)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(submissions[0].Score, gocheck.Equals, score)
+
+	user, err := moderation.Approve("arthur")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Rank, gocheck.Equals, 1)
+	c.Assert(board.TotalMembers(), gocheck.Equals, 2)
+	c.Assert(board.GetRank("felipe"), gocheck.Equals, 0)
+}
@@ -0,0 +1,245 @@
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Option configures a leaderboard created with New.
+type Option func(*Leaderboard)
+
+// Logger receives the errors a leaderboard reports. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Metrics receives the outcome of every command a leaderboard sends to Redis.
+// Pipelined commands are timed from the flush of the pipeline to their reply.
+type Metrics interface {
+	ObserveCommand(command string, duration time.Duration, err error)
+}
+
+// Backend hands out connections to Redis. *redis.Pool satisfies it.
+type Backend interface {
+	Get() redis.Conn
+}
+
+// instrumentedConn reports the commands going through a connection to a
+// Metrics.
+type instrumentedConn struct {
+	redis.Conn
+	metrics Metrics
+	pending []string
+	flushed time.Time
+}
+
+/* End Structs model */
+
+/* Private functions */
+
+func (c *instrumentedConn) Do(command string, args ...interface{}) (interface{}, error) {
+	start := time.Now()
+	reply, err := c.Conn.Do(command, args...)
+	duration := time.Since(start)
+	for _, pending := range c.pending {
+		c.metrics.ObserveCommand(pending, duration, err)
+	}
+	c.pending = nil
+	if command != "" {
+		c.metrics.ObserveCommand(command, duration, err)
+	}
+	return reply, err
+}
+
+func (c *instrumentedConn) Send(command string, args ...interface{}) error {
+	c.pending = append(c.pending, command)
+	return c.Conn.Send(command, args...)
+}
+
+func (c *instrumentedConn) Flush() error {
+	c.flushed = time.Now()
+	return c.Conn.Flush()
+}
+
+func (c *instrumentedConn) Receive() (interface{}, error) {
+	reply, err := c.Conn.Receive()
+	if len(c.pending) > 0 {
+		if _, ok := reply.(redis.Error); ok && err == nil {
+			err = reply.(redis.Error)
+		}
+		c.metrics.ObserveCommand(c.pending[0], time.Since(c.flushed), err)
+		c.pending = c.pending[1:]
+	}
+	return reply, err
+}
+
+func (l *Leaderboard) validate() error {
+	if l.Name == "" {
+		return errors.New("leaderboard: name must not be empty")
+	}
+	if l.PageSize < 1 {
+		return fmt.Errorf("leaderboard: page size must be positive, got %d", l.PageSize)
+	}
+	if l.Order != HighToLow && l.Order != LowToHigh {
+		return fmt.Errorf("leaderboard: unknown sort order %d", l.Order)
+	}
+	if l.ties != TieByMember && l.ties != TieShared {
+		return fmt.Errorf("leaderboard: unknown tie policy %d", l.ties)
+	}
+	if l.ghosts != NoGhosts && l.ghosts != GhostsRanked && l.ghosts != GhostsUnranked {
+		return fmt.Errorf("leaderboard: unknown ghost policy %d", l.ghosts)
+	}
+	if l.aggregate != AggregateBest && l.aggregate != AggregateSum {
+		return fmt.Errorf("leaderboard: unknown aggregation %d", l.aggregate)
+	}
+	if l.cap < 0 {
+		return fmt.Errorf("leaderboard: cap must not be negative, got %d", l.cap)
+	}
+	for _, window := range l.windows {
+		if _, ok := windowNames[window]; !ok {
+			return fmt.Errorf("leaderboard: unknown window %d", window)
+		}
+	}
+	for name, filter := range l.segments {
+		if name == "" {
+			return errors.New("leaderboard: segment name must not be empty")
+		}
+		for field, values := range filter {
+			if len(values) == 0 {
+				return fmt.Errorf("leaderboard: field %q of segment %q has no values", field, name)
+			}
+		}
+	}
+	if l.top != nil {
+		if l.top.size < l.PageSize {
+			return fmt.Errorf("leaderboard: top snapshot must hold a page of %d members, got %d", l.PageSize, l.top.size)
+		}
+		if l.top.replicas < 0 {
+			return fmt.Errorf("leaderboard: top snapshot replicas must not be negative, got %d", l.top.replicas)
+		}
+		if l.top.interval < time.Millisecond {
+			return fmt.Errorf("leaderboard: top snapshot interval must be at least a millisecond, got %s", l.top.interval)
+		}
+	}
+	if l.backend == nil && l.Settings.Host == "" {
+		return errors.New("leaderboard: redis host must not be empty without a backend")
+	}
+	return nil
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// New creates a leaderboard configured by options and validates it.
+func New(settings RedisSettings, name string, options ...Option) (Leaderboard, error) {
+	l := Leaderboard{Settings: settings, Name: name, PageSize: DefaultPageSize}
+	for _, option := range options {
+		option(&l)
+	}
+	if err := l.validate(); err != nil {
+		return Leaderboard{}, err
+	}
+	return l, nil
+}
+
+// WithPageSize sets the number of members per page. Defaults to
+// DefaultPageSize.
+func WithPageSize(pageSize int) Option {
+	return func(l *Leaderboard) {
+		l.PageSize = pageSize
+	}
+}
+
+// WithSortOrder sets which end of the score range is ranked first. Defaults
+// to HighToLow.
+func WithSortOrder(order SortOrder) Option {
+	return func(l *Leaderboard) {
+		l.Order = order
+	}
+}
+
+// WithTiePolicy sets how equal scores are ranked. Defaults to TieByMember.
+func WithTiePolicy(ties TiePolicy) Option {
+	return func(l *Leaderboard) {
+		l.ties = ties
+	}
+}
+
+// WithGhostPolicy sets how ghosts are shown. Defaults to NoGhosts.
+func WithGhostPolicy(policy GhostPolicy) Option {
+	return func(l *Leaderboard) {
+		l.ghosts = policy
+	}
+}
+
+// WithAggregation sets how the leaderboard combines the scores of a member
+// on its children in a Registry. Defaults to AggregateBest.
+func WithAggregation(aggregation Aggregation) Option {
+	return func(l *Leaderboard) {
+		l.aggregate = aggregation
+	}
+}
+
+// WithCap keeps only the members ranked in the first members places:
+// RankMember and Batch.RankMember drop the members ranked past them, ghosts
+// included. Zero, the default, keeps every member.
+func WithCap(members int) Option {
+	return func(l *Leaderboard) {
+		l.cap = members
+	}
+}
+
+// WithEntries makes RankMember, RemoveMember, their Batch counterparts and
+// the cap keep the accounts of the entries ranked with Entries in step.
+// NewEntries turns it on for its own leaderboard.
+func WithEntries() Option {
+	return func(l *Leaderboard) {
+		l.entries = true
+	}
+}
+
+// WithKeyPrefix prepends prefix to every Redis key of the leaderboard.
+func WithKeyPrefix(prefix string) Option {
+	return func(l *Leaderboard) {
+		l.keyPrefix = prefix
+	}
+}
+
+// WithLogger sends the errors of the leaderboard to logger instead of stdout.
+func WithLogger(logger Logger) Option {
+	return func(l *Leaderboard) {
+		l.logger = logger
+	}
+}
+
+// WithMetrics reports every command sent to Redis to metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(l *Leaderboard) {
+		l.metrics = metrics
+	}
+}
+
+// WithClock makes the time-dependent features of the leaderboard read the
+// time from clock, the idle timeout of its shared connections included.
+// Defaults to SystemClock.
+func WithClock(clock Clock) Option {
+	return func(l *Leaderboard) {
+		l.clock = clock
+	}
+}
+
+// WithBackend takes connections from backend instead of the pool shared by
+// leaderboards created from RedisSettings.
+func WithBackend(backend Backend) Option {
+	return func(l *Leaderboard) {
+		l.backend = backend
+	}
+}
+
+/* End Public functions */
@@ -0,0 +1,134 @@
+package leaderboard
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"launchpad.net/gocheck"
+)
+
+type countingMetrics struct {
+	commands map[string]int
+}
+
+func (m *countingMetrics) ObserveCommand(command string, duration time.Duration, err error) {
+	m.commands[command]++
+}
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (s *S) TestNewValidatesSettings(c *gocheck.C) {
+	_, err := New(redisSettings, "options", WithPageSize(0))
+	c.Assert(err, gocheck.ErrorMatches, "leaderboard: page size must be positive, got 0")
+	_, err = New(redisSettings, "")
+	c.Assert(err, gocheck.NotNil)
+	_, err = New(RedisSettings{}, "options")
+	c.Assert(err, gocheck.NotNil)
+	_, err = New(RedisSettings{}, "options", WithBackend(newPool(redisSettings.Host, "", SystemClock)))
+	c.Assert(err, gocheck.IsNil)
+	_, err = New(redisSettings, "options", WithSortOrder(SortOrder(7)))
+	c.Assert(err, gocheck.NotNil)
+	_, err = New(redisSettings, "options", WithGhostPolicy(GhostPolicy(3)))
+	c.Assert(err, gocheck.ErrorMatches, "leaderboard: unknown ghost policy 3")
+
+	board, err := New(redisSettings, "options")
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(board.PageSize, gocheck.Equals, DefaultPageSize)
+}
+
+func (s *S) TestNewLeaderboardDefaultsPageSize(c *gocheck.C) {
+	board := NewLeaderboard(redisSettings, "options", -1)
+	c.Assert(board.PageSize, gocheck.Equals, DefaultPageSize)
+	c.Assert(board.TotalPages(), gocheck.Equals, 0)
+}
+
+func (s *S) TestWithKeyPrefix(c *gocheck.C) {
+	board, _ := New(redisSettings, "prefixed", WithKeyPrefix("game:"))
+	board.RankMember("dayvson", 10)
+	conn := getConnection(redisSettings, SystemClock)
+	defer conn.Close()
+	exists, _ := conn.Do("EXISTS", "game:prefixed")
+	c.Assert(exists, gocheck.Equals, int64(1))
+	c.Assert(board.GetRank("dayvson"), gocheck.Equals, 1)
+}
+
+func (s *S) TestWithTiePolicy(c *gocheck.C) {
+	board, _ := New(redisSettings, "sharedTies", WithTiePolicy(TieShared), WithPageSize(10))
+	board.RankMember("dayvson", 300)
+	board.RankMember("arthur", 200)
+	board.RankMember("felipe", 200)
+	board.RankMember("maxwell", 100)
+	c.Assert(board.GetRank("arthur"), gocheck.Equals, 2)
+	c.Assert(board.GetRank("felipe"), gocheck.Equals, 2)
+	c.Assert(board.GetRank("maxwell"), gocheck.Equals, 4)
+	users := board.GetLeaders(1)
+	ranks := []int{}
+	for _, user := range users[:4] {
+		ranks = append(ranks, user.Rank)
+	}
+	c.Assert(ranks, gocheck.DeepEquals, []int{1, 2, 2, 4})
+	c.Assert(board.GetMemberByRank(3).Rank, gocheck.Equals, 2)
+}
+
+func (s *S) TestWithSortOrder(c *gocheck.C) {
+	board, _ := New(redisSettings, "bestLap", WithSortOrder(LowToHigh), WithTiePolicy(TieShared))
+	for i := 0; i < 5; i++ {
+		board.RankMember("member_"+strconv.Itoa(i), 60+i)
+	}
+	board.RankMember("member_5", 60)
+	c.Assert(board.GetRank("member_0"), gocheck.Equals, 1)
+	c.Assert(board.GetRank("member_5"), gocheck.Equals, 1)
+	c.Assert(board.GetRank("member_4"), gocheck.Equals, 6)
+}
+
+func (s *S) TestWithCap(c *gocheck.C) {
+	kills, _ := New(redisSettings, "capped", WithCap(2))
+	kills.RankMember("dayvson", 10)
+	kills.RankMember("felipe", 30)
+	user, _ := kills.RankMember("arthur", 20)
+	c.Assert(user.Rank, gocheck.Equals, 2)
+	c.Assert(kills.TotalMembers(), gocheck.Equals, 2)
+	c.Assert(kills.GetRank("dayvson"), gocheck.Equals, 0)
+
+	laps, _ := New(redisSettings, "cappedLaps", WithCap(2), WithSortOrder(LowToHigh))
+	batch := NewBatch()
+	batch.RankMember(&laps, "dayvson", 61)
+	batch.RankMember(&laps, "felipe", 58)
+	arthur := batch.RankMember(&laps, "arthur", 59)
+	c.Assert(batch.Exec(), gocheck.IsNil)
+	user, err := arthur.Result()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Rank, gocheck.Equals, 2)
+	c.Assert(laps.GetRank("dayvson"), gocheck.Equals, 0)
+
+	_, err = New(redisSettings, "capped", WithCap(-1))
+	c.Assert(err, gocheck.ErrorMatches, "leaderboard: cap must not be negative, got -1")
+}
+
+func (s *S) TestWithMetricsAndLogger(c *gocheck.C) {
+	metrics := &countingMetrics{commands: map[string]int{}}
+	logger := &recordingLogger{}
+	board, _ := New(redisSettings, "observed", WithMetrics(metrics), WithLogger(logger))
+	board.RankMember("dayvson", 10)
+	board.TotalMembers()
+	c.Assert(metrics.commands["ZADD"], gocheck.Equals, 2)
+	c.Assert(metrics.commands["ZCARD"], gocheck.Equals, 1)
+
+	CompareMembers([]Leaderboard{board}, "dayvson", "arthur")
+	c.Assert(metrics.commands["EVAL"], gocheck.Equals, 2)
+
+	board.RankMember("dayvson", 10)
+	c.Assert(len(logger.lines), gocheck.Equals, 0)
+	conn := getConnection(redisSettings, SystemClock)
+	conn.Do("SET", "observed", "not a sorted set")
+	conn.Close()
+	board.RankMember("dayvson", 10)
+	c.Assert(len(logger.lines), gocheck.Equals, 2)
+}
@@ -0,0 +1,52 @@
+package leaderboard
+
+import (
+	"fmt"
+)
+
+/* Structs model */
+
+// Registry holds leaderboards by name, keeping the order they were
+// registered in. Leaderboards registered as children of another form a
+// hierarchy, e.g. game, mode and map leaderboards.
+type Registry struct {
+	boards   map[string]Leaderboard
+	names    []string
+	parents  map[string]string
+	children map[string][]string
+}
+
+/* End Structs model */
+
+/* Public functions */
+
+func NewRegistry() *Registry {
+	return &Registry{boards: map[string]Leaderboard{}, parents: map[string]string{}, children: map[string][]string{}}
+}
+
+// Register adds a leaderboard. Names must be unique within a registry.
+func (r *Registry) Register(l Leaderboard) error {
+	if _, ok := r.boards[l.Name]; ok {
+		return fmt.Errorf("leaderboard: %q is already registered", l.Name)
+	}
+	r.boards[l.Name] = l
+	r.names = append(r.names, l.Name)
+	return nil
+}
+
+// Get returns the leaderboard registered under name.
+func (r *Registry) Get(name string) (Leaderboard, bool) {
+	l, ok := r.boards[name]
+	return l, ok
+}
+
+// Boards returns the registered leaderboards in registration order.
+func (r *Registry) Boards() []Leaderboard {
+	boards := make([]Leaderboard, len(r.names))
+	for i, name := range r.names {
+		boards[i] = r.boards[name]
+	}
+	return boards
+}
+
+/* End Public functions */
@@ -0,0 +1,251 @@
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// UpdatePolicy tells how a replicated leaderboard combines the scores of a
+// member, so that deployments applying the same updates in any order agree.
+type UpdatePolicy int
+
+const (
+	// UpdateMax keeps the highest score submitted.
+	UpdateMax UpdatePolicy = iota
+	// UpdateMin keeps the lowest score submitted.
+	UpdateMin
+	// UpdateSum adds up the deltas submitted.
+	UpdateSum
+	// UpdateLastWrite keeps the score submitted last, by the clock of the
+	// leaderboard, ties going to the greatest origin.
+	UpdateLastWrite
+)
+
+// ReplicatedBoard is a leaderboard whose updates are logged, to be shipped to
+// the other deployments by a Replicator. Origin names the deployment, e.g.
+// its region, and must differ between deployments. The log keeps the last
+// OplogLength updates; a deployment left behind for longer misses updates.
+type ReplicatedBoard struct {
+	Leaderboard Leaderboard
+	Policy      UpdatePolicy
+	Origin      string
+	OplogLength int
+}
+
+// Replicator ships the updates logged on Source to Target. Run one
+// replicator each way to keep two deployments converged.
+type Replicator struct {
+	Source    ReplicatedBoard
+	Target    ReplicatedBoard
+	BatchSize int
+}
+
+/* End Structs model */
+
+// DefaultOplogLength is how many updates a replicated leaderboard logs when
+// created without a length.
+const DefaultOplogLength = 100000
+
+// ErrRemoveNotReplicated is returned by Remove on a board whose policy is not
+// UpdateLastWrite: a removal racing a Submit on another deployment would
+// leave the deployments apart for good, as the scores they combine do not
+// tell which submissions came after the removal.
+var ErrRemoveNotReplicated = errors.New("leaderboard: remove needs the UpdateLastWrite policy")
+
+// updateScript applies an update according to the update policy, logs it
+// when asked to and moves the cursor of a replicator when given one. It
+// returns 1 when the update was applied.
+// KEYS: board, log, write times, replicator cursor.
+// ARGV: operation, update policy, member, value, time in milliseconds,
+// origin, log length or 0 not to log, log entry replicated or empty.
+var updateScript = redis.NewScript(4, `
+local op, policy, member, value = ARGV[1], ARGV[2], ARGV[3], tonumber(ARGV[4])
+local applied = true
+if policy == '3' then
+	local stored = redis.call('HGET', KEYS[3], member)
+	if stored then
+		local sep = string.find(stored, ':')
+		local at, incoming = tonumber(string.sub(stored, 1, sep - 1)), tonumber(ARGV[5])
+		applied = incoming > at or (incoming == at and ARGV[6] > string.sub(stored, sep + 1))
+	end
+	if applied then
+		redis.call('HSET', KEYS[3], member, ARGV[5] .. ':' .. ARGV[6])
+	end
+end
+if applied then
+	if op == 'del' then
+		redis.call('ZREM', KEYS[1], member)
+	elseif policy == '2' then
+		redis.call('ZINCRBY', KEYS[1], value, member)
+	else
+		local current = tonumber(redis.call('ZSCORE', KEYS[1], member))
+		if not current or policy == '3' or (policy == '0' and value > current) or (policy == '1' and value < current) then
+			redis.call('ZADD', KEYS[1], value, member)
+		end
+	end
+end
+if ARGV[7] ~= '0' then
+	redis.call('XADD', KEYS[2], 'MAXLEN', ARGV[7], '*', 'op', op, 'member', member, 'value', ARGV[4], 'at', ARGV[5], 'origin', ARGV[6])
+end
+if ARGV[8] ~= '' then
+	redis.call('SET', KEYS[4], ARGV[8])
+end
+if applied then
+	return 1
+end
+return 0
+`)
+
+/* Private functions */
+
+func (r *ReplicatedBoard) oplogKey() string {
+	return r.Leaderboard.subKey(":oplog")
+}
+
+func (r *ReplicatedBoard) writesKey() string {
+	return r.Leaderboard.subKey(":writes")
+}
+
+func (r *ReplicatedBoard) cursorKey(origin string) string {
+	return r.Leaderboard.subKey(":replicated:" + origin)
+}
+
+// update applies an update on the board. Local updates are logged, updates
+// shipped from source move the cursor of source.
+func (r *ReplicatedBoard) update(conn redis.Conn, op string, member string, value int, at int64, origin string, source string, id string) (bool, error) {
+	length := 0
+	if source == "" {
+		length = r.OplogLength
+	}
+	applied, err := redis.Int(updateScript.Do(conn, r.Leaderboard.key(), r.oplogKey(), r.writesKey(), r.cursorKey(source),
+		op, int(r.Policy), member, value, at, origin, length, id))
+	return applied == 1, err
+}
+
+func (r *ReplicatedBoard) submit(op string, member string, value int) (User, error) {
+	conn := r.Leaderboard.conn()
+	defer conn.Close()
+	at := r.Leaderboard.now().UnixNano() / 1e6
+	if _, err := r.update(conn, op, member, value, at, r.Origin, "", ""); err != nil {
+		return User{Name: member}, err
+	}
+	return r.Leaderboard.getMember(conn, member)
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// NewReplicatedBoard logs the updates of board made through it. A length
+// below one defaults to DefaultOplogLength.
+func NewReplicatedBoard(board Leaderboard, policy UpdatePolicy, origin string, length int) ReplicatedBoard {
+	if length < 1 {
+		length = DefaultOplogLength
+	}
+	return ReplicatedBoard{Leaderboard: board, Policy: policy, Origin: origin, OplogLength: length}
+}
+
+// Submit updates a member according to the update policy: value is a score,
+// or the delta to add with UpdateSum. It returns the member as ranked after
+// the update.
+func (r *ReplicatedBoard) Submit(member string, value int) (User, error) {
+	result, err := r.Leaderboard.invoke("ReplicatedBoard.Submit", []interface{}{member, value}, func(args []interface{}) (interface{}, error) {
+		return r.submit("set", args[0].(string), args[1].(int))
+	})
+	user, _ := result.(User)
+	return user, err
+}
+
+// Remove removes a member on every deployment. It needs UpdateLastWrite,
+// under which the later of a removal and a Submit racing on another
+// deployment wins; other policies return ErrRemoveNotReplicated.
+func (r *ReplicatedBoard) Remove(member string) error {
+	if r.Policy != UpdateLastWrite {
+		return ErrRemoveNotReplicated
+	}
+	_, err := r.Leaderboard.invoke("ReplicatedBoard.Remove", []interface{}{member}, func(args []interface{}) (interface{}, error) {
+		_, err := r.submit("del", args[0].(string), 0)
+		if err == redis.ErrNil {
+			return nil, nil
+		}
+		return nil, err
+	})
+	return err
+}
+
+// NewReplicator ships the updates of source to target in batches of
+// batchSize, 100 when below one.
+func NewReplicator(source ReplicatedBoard, target ReplicatedBoard, batchSize int) (Replicator, error) {
+	if source.Origin == target.Origin {
+		return Replicator{}, errors.New("leaderboard: replicated boards must have distinct origins")
+	}
+	if source.Policy != target.Policy {
+		return Replicator{}, errors.New("leaderboard: replicated boards must share their update policy")
+	}
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	return Replicator{Source: source, Target: target, BatchSize: batchSize}, nil
+}
+
+// Sync applies the updates logged on Source since the previous sync to
+// Target and returns how many were shipped. Every update is applied along
+// with the cursor of the replicator, so an interrupted sync resumes without
+// applying an update twice.
+func (r *Replicator) Sync() (int, error) {
+	source, target := r.Source.Leaderboard.conn(), r.Target.Leaderboard.conn()
+	defer source.Close()
+	defer target.Close()
+	cursor, err := redis.String(target.Do("GET", r.Target.cursorKey(r.Source.Origin)))
+	if err == redis.ErrNil {
+		cursor = "0"
+	} else if err != nil {
+		return 0, err
+	}
+	shipped := 0
+	for {
+		streams, err := redis.Values(source.Do("XREAD", "COUNT", r.BatchSize, "STREAMS", r.Source.oplogKey(), cursor))
+		if err == redis.ErrNil {
+			return shipped, nil
+		}
+		if err != nil {
+			return shipped, err
+		}
+		var stream []interface{}
+		if _, err := redis.Scan(streams, &stream); err != nil {
+			return shipped, err
+		}
+		entries, err := redis.Values(stream[1], nil)
+		if err != nil {
+			return shipped, err
+		}
+		for _, entry := range entries {
+			values, err := redis.Values(entry, nil)
+			if err != nil || len(values) != 2 {
+				return shipped, fmt.Errorf("leaderboard: unexpected log entry %v", entry)
+			}
+			id, _ := redis.String(values[0], nil)
+			fields, err := redis.StringMap(values[1], nil)
+			if err != nil {
+				return shipped, err
+			}
+			value, _ := strconv.Atoi(fields["value"])
+			at, _ := strconv.ParseInt(fields["at"], 10, 64)
+			if _, err := r.Target.update(target, fields["op"], fields["member"], value, at, fields["origin"], r.Source.Origin, id); err != nil {
+				return shipped, err
+			}
+			cursor = id
+			shipped++
+		}
+		if len(entries) < r.BatchSize {
+			return shipped, nil
+		}
+	}
+}
+
+/* End Public functions */
@@ -0,0 +1,124 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"launchpad.net/gocheck"
+)
+
+// newRegions returns an east board on the test Redis and a west board on a
+// Redis of its own, replicated both ways. The west Redis is closed after the
+// test.
+func (s *S) newRegions(c *gocheck.C, name string, policy UpdatePolicy, clock Clock) (ReplicatedBoard, ReplicatedBoard, Replicator, Replicator) {
+	westRedis, err := miniredis.Run()
+	c.Assert(err, gocheck.IsNil)
+	s.servers = append(s.servers, westRedis)
+	c.Assert(westRedis.Addr(), gocheck.Not(gocheck.Equals), redisSettings.Host)
+	eastBoard, err := New(redisSettings, name, WithKeyPrefix("east:"), WithClock(clock))
+	c.Assert(err, gocheck.IsNil)
+	westBoard, err := New(RedisSettings{}, name, WithBackend(newPool(westRedis.Addr(), "", clock)), WithClock(clock))
+	c.Assert(err, gocheck.IsNil)
+	east := NewReplicatedBoard(eastBoard, policy, "east", 0)
+	west := NewReplicatedBoard(westBoard, policy, "west", 0)
+	toWest, err := NewReplicator(east, west, 2)
+	c.Assert(err, gocheck.IsNil)
+	toEast, err := NewReplicator(west, east, 2)
+	c.Assert(err, gocheck.IsNil)
+	return east, west, toWest, toEast
+}
+
+func (s *S) TestReplicateMax(c *gocheck.C) {
+	east, west, toWest, toEast := s.newRegions(c, "replicatedMax", UpdateMax, SystemClock)
+	east.Submit("dayvson", 50)
+	east.Submit("dayvson", 30)
+	west.Submit("dayvson", 40)
+	west.Submit("felipe", 10)
+	east.Submit("arthur", 20)
+
+	shipped, err := toWest.Sync()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(shipped, gocheck.Equals, 3)
+	shipped, _ = toEast.Sync()
+	c.Assert(shipped, gocheck.Equals, 2)
+	shipped, _ = toWest.Sync()
+	c.Assert(shipped, gocheck.Equals, 0)
+
+	for _, region := range []ReplicatedBoard{east, west} {
+		user, _ := region.Leaderboard.GetMember("dayvson")
+		c.Assert(user.Score, gocheck.Equals, 50)
+		c.Assert(region.Leaderboard.TotalMembers(), gocheck.Equals, 3)
+	}
+
+	c.Assert(west.Remove("felipe"), gocheck.Equals, ErrRemoveNotReplicated)
+	c.Assert(west.Leaderboard.GetRank("felipe"), gocheck.Equals, 3)
+}
+
+func (s *S) TestReplicateSum(c *gocheck.C) {
+	east, west, toWest, toEast := s.newRegions(c, "replicatedSum", UpdateSum, SystemClock)
+	east.Submit("dayvson", 5)
+	user, _ := west.Submit("dayvson", 7)
+	c.Assert(user.Score, gocheck.Equals, 7)
+	east.Submit("dayvson", -2)
+	toWest.Sync()
+	toEast.Sync()
+	toEast.Sync()
+	for _, region := range []ReplicatedBoard{east, west} {
+		user, _ := region.Leaderboard.GetMember("dayvson")
+		c.Assert(user.Score, gocheck.Equals, 10)
+	}
+}
+
+func (s *S) TestReplicateLastWrite(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 12, 0, 0, 0, time.UTC))
+	east, west, toWest, toEast := s.newRegions(c, "replicatedLast", UpdateLastWrite, clock)
+	west.Submit("dayvson", 10)
+	clock.Advance(time.Second)
+	east.Submit("dayvson", 5)
+	east.Submit("felipe", 1)
+	west.Submit("felipe", 2)
+	toEast.Sync()
+	toWest.Sync()
+	for _, region := range []ReplicatedBoard{east, west} {
+		user, _ := region.Leaderboard.GetMember("dayvson")
+		c.Assert(user.Score, gocheck.Equals, 5)
+		user, _ = region.Leaderboard.GetMember("felipe")
+		c.Assert(user.Score, gocheck.Equals, 2)
+	}
+
+	clock.Advance(time.Second)
+	east.Remove("dayvson")
+	clock.Advance(-time.Millisecond)
+	west.Submit("dayvson", 7)
+	toWest.Sync()
+	toEast.Sync()
+	c.Assert(east.Leaderboard.GetRank("dayvson"), gocheck.Equals, 0)
+	c.Assert(west.Leaderboard.GetRank("dayvson"), gocheck.Equals, 0)
+}
+
+func (s *S) TestNewReplicatorValidates(c *gocheck.C) {
+	board := NewLeaderboard(redisSettings, "replicated", 10)
+	_, err := NewReplicator(NewReplicatedBoard(board, UpdateMax, "east", 0), NewReplicatedBoard(board, UpdateMax, "east", 0), 0)
+	c.Assert(err, gocheck.NotNil)
+	_, err = NewReplicator(NewReplicatedBoard(board, UpdateMax, "east", 0), NewReplicatedBoard(board, UpdateSum, "west", 0), 0)
+	c.Assert(err, gocheck.NotNil)
+}
+
+func (s *S) TestReplicateRemoveRacingSubmit(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 12, 0, 0, 0, time.UTC))
+	east, west, toWest, toEast := s.newRegions(c, "replicatedRace", UpdateLastWrite, clock)
+	east.Submit("felipe", 10)
+	toWest.Sync()
+	clock.Advance(time.Second)
+	c.Assert(east.Remove("felipe"), gocheck.IsNil)
+	clock.Advance(time.Millisecond)
+	west.Submit("felipe", 5)
+	toWest.Sync()
+	toEast.Sync()
+	for _, region := range []ReplicatedBoard{east, west} {
+		user, err := region.Leaderboard.GetMember("felipe")
+		c.Assert(err, gocheck.IsNil)
+		c.Assert(user.Score, gocheck.Equals, 5)
+		c.Assert(user.Rank, gocheck.Equals, 1)
+	}
+}
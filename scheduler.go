@@ -0,0 +1,341 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/robfig/cron/v3"
+)
+
+/* Structs model */
+
+// Schedule tells when a job runs next.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Job is a maintenance task run by a Scheduler, such as a streak sweep or a
+// rank history sample.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context, run JobRun) error
+}
+
+// JobRun describes one execution of a job. Token is a fencing token: it
+// increases with every lock acquisition of the job, so a job may tell a
+// write made by a run that lost its lock from the writes of the next run.
+// It is advisory: the jobs built by this package do not check it, as their
+// writes are safe to repeat, and a run outliving its lock at worst redoes
+// the work of the next one.
+type JobRun struct {
+	Name  string
+	Time  time.Time
+	Token int64
+}
+
+// Scheduler runs jobs on their schedule across a fleet of instances sharing a
+// Redis. Each run of a job takes a lock with a fencing token, renewed while
+// the job runs, so a single instance executes it. A run that completed is
+// recorded and never executed again.
+type Scheduler struct {
+	Settings  RedisSettings
+	Backend   Backend
+	KeyPrefix string
+	LockTTL   time.Duration
+	Logger    Logger
+	Clock     Clock
+
+	jobs []Job
+}
+
+type intervalSchedule time.Duration
+
+/* End Structs model */
+
+// ErrLockLost is returned by a run whose lock expired before it ended.
+var ErrLockLost = errors.New("leaderboard: job lock lost")
+
+// renewLockScript extends a lock held with the token in ARGV[1].
+var renewLockScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLockScript deletes a lock held with the token in ARGV[1].
+var releaseLockScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// completeRunScript records the time of a run, as long as the lock is still
+// held with the token in ARGV[1].
+var completeRunScript = redis.NewScript(2, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[2], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+/* Private functions */
+
+func (i intervalSchedule) Next(after time.Time) time.Time {
+	return after.Truncate(time.Duration(i)).Add(time.Duration(i))
+}
+
+func (s *Scheduler) conn() redis.Conn {
+	if s.Backend != nil {
+		return s.Backend.Get()
+	}
+	return getConnection(s.Settings, s.clock())
+}
+
+func (s *Scheduler) clock() Clock {
+	if s.Clock != nil {
+		return s.Clock
+	}
+	return SystemClock
+}
+
+func (s *Scheduler) logf(format string, v ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, v...)
+	}
+}
+
+func (s *Scheduler) lockTTL() time.Duration {
+	if s.LockTTL < time.Second {
+		return 30 * time.Second
+	}
+	return s.LockTTL
+}
+
+func (s *Scheduler) key(job string, suffix string) string {
+	return s.KeyPrefix + "job:" + job + ":" + suffix
+}
+
+// runJob executes job on its schedule until ctx is done, or until its
+// schedule stops moving forward.
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	clock := s.clock()
+	after := clock.Now()
+	next := job.Schedule.Next(after)
+	for next.After(after) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(next.Sub(clock.Now())):
+		}
+		if _, err := s.Execute(ctx, job, next); err != nil {
+			s.logf("error on run job:%s - Time:%s - %s", job.Name, next, err)
+		}
+		after = next
+		next = job.Schedule.Next(after)
+		if now := clock.Now(); next.Before(now) {
+			after = now
+			next = job.Schedule.Next(after)
+		}
+	}
+	s.logf("error on schedule job:%s - no run after %s", job.Name, after)
+}
+
+// renew keeps the lock of a run alive until done is closed, and cancels the
+// run when the lock is lost.
+func (s *Scheduler) renew(lockKey string, token int64, cancel context.CancelFunc, done chan struct{}) {
+	ttl := s.lockTTL()
+	clock := s.clock()
+	for {
+		select {
+		case <-done:
+			return
+		case <-clock.After(ttl / 3):
+		}
+		conn := s.conn()
+		renewed, err := redis.Int(renewLockScript.Do(conn, lockKey, token, int64(ttl/time.Millisecond)))
+		conn.Close()
+		if err == nil && renewed == 0 {
+			cancel()
+			return
+		}
+	}
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// Every returns a schedule firing at every multiple of interval since the
+// zero time, e.g. every hour on the hour. The interval must be positive.
+func Every(interval time.Duration) (Schedule, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("leaderboard: schedule interval must be positive, got %s", interval)
+	}
+	return intervalSchedule(interval), nil
+}
+
+// ParseSchedule parses a standard five field cron expression, e.g.
+// "0 0 * * *" for every day at midnight, or a descriptor such as "@hourly".
+func ParseSchedule(spec string) (Schedule, error) {
+	return cron.ParseStandard(spec)
+}
+
+// Add registers a job to be run by Run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Run executes the registered jobs on their schedule until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.runJob(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// Execute runs job for its scheduled time unless another instance holds its
+// lock or the run already completed. It reports whether the job ran.
+func (s *Scheduler) Execute(ctx context.Context, job Job, scheduled time.Time) (bool, error) {
+	lockKey, lastKey := s.key(job.Name, "lock"), s.key(job.Name, "last")
+	ttl := s.lockTTL()
+	conn := s.conn()
+	defer conn.Close()
+	token, err := redis.Int64(conn.Do("INCR", s.key(job.Name, "fence")))
+	if err != nil {
+		return false, err
+	}
+	_, err = redis.String(conn.Do("SET", lockKey, token, "NX", "PX", int64(ttl/time.Millisecond)))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer releaseLockScript.Do(conn, lockKey, token)
+	last, err := redis.Int64(conn.Do("GET", lastKey))
+	if err != nil && err != redis.ErrNil {
+		return false, err
+	}
+	if err == nil && last >= scheduled.UnixNano() {
+		return false, nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go s.renew(lockKey, token, cancel, done)
+	err = job.Run(runCtx, JobRun{Name: job.Name, Time: scheduled, Token: token})
+	close(done)
+	if err != nil && runCtx.Err() != nil && ctx.Err() == nil {
+		return true, ErrLockLost
+	}
+	if err != nil {
+		return true, err
+	}
+	completed, err := redis.Int(completeRunScript.Do(conn, lockKey, lastKey, token, scheduled.UnixNano()))
+	if err != nil {
+		return true, err
+	}
+	if completed == 0 {
+		return true, ErrLockLost
+	}
+	return true, nil
+}
+
+// SweepJob returns a job breaking stale streaks, to be scheduled once a
+// period. Jobs are named after the Redis keys of their boards, so boards of
+// the same name under different key prefixes get distinct jobs.
+func (s *StreakBoard) SweepJob(schedule Schedule) Job {
+	return Job{
+		Name:     s.key() + ":sweep",
+		Schedule: schedule,
+		Run: func(ctx context.Context, run JobRun) error {
+			_, err := s.BreakStale(run.Time)
+			return err
+		},
+	}
+}
+
+// CleanupJob returns a job removing the stale days of a daily board, to be
+// scheduled once a day.
+func (d *DailyBoard) CleanupJob(schedule Schedule) Job {
+	return Job{
+		Name:     d.Leaderboard.key() + ":cleanup",
+		Schedule: schedule,
+		Run: func(ctx context.Context, run JobRun) error {
+			_, err := d.Cleanup(run.Time)
+			return err
+		},
+	}
+}
+
+// SyncJob returns a job shipping the updates of the source of a replicator
+// to its target.
+func (r *Replicator) SyncJob(schedule Schedule) Job {
+	return Job{
+		Name:     r.Target.Leaderboard.key() + ":replicate:" + r.Source.Origin + ":" + r.Target.Origin,
+		Schedule: schedule,
+		Run: func(ctx context.Context, run JobRun) error {
+			_, err := r.Sync()
+			return err
+		},
+	}
+}
+
+// MigrateJob returns a job migrating boards to the latest layout, named after
+// the keys of the boards.
+func (m *Migrator) MigrateJob(schedule Schedule, boards ...Leaderboard) Job {
+	keys := make([]string, len(boards))
+	for i := range boards {
+		keys[i] = boards[i].key()
+	}
+	return Job{
+		Name:     "migrate:" + strings.Join(keys, ","),
+		Schedule: schedule,
+		Run: func(ctx context.Context, run JobRun) error {
+			for i := range boards {
+				if err := m.Migrate(ctx, &boards[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// SampleJob returns a job sampling the n first placed players and compacting
+// their history.
+func (h *RankHistory) SampleJob(schedule Schedule, n int) Job {
+	return Job{
+		Name:     h.Leaderboard.key() + ":history",
+		Schedule: schedule,
+		Run: func(ctx context.Context, run JobRun) error {
+			players, err := h.sampleTop(run.Time, n)
+			if err != nil {
+				return err
+			}
+			for _, user := range players {
+				if err := h.Compact(user.Name, run.Time); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+/* End Public functions */
@@ -0,0 +1,141 @@
+package leaderboard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestExecuteRunsOnce(c *gocheck.C) {
+	scheduler := &Scheduler{Settings: redisSettings, KeyPrefix: "test:"}
+	runs := []JobRun{}
+	job := Job{Name: "once", Run: func(ctx context.Context, run JobRun) error {
+		runs = append(runs, run)
+		return nil
+	}}
+	scheduled := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	ran, err := scheduler.Execute(context.Background(), job, scheduled)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(ran, gocheck.Equals, true)
+	ran, err = scheduler.Execute(context.Background(), job, scheduled)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(ran, gocheck.Equals, false)
+	ran, _ = scheduler.Execute(context.Background(), job, scheduled.Add(time.Hour))
+	c.Assert(ran, gocheck.Equals, true)
+
+	c.Assert(len(runs), gocheck.Equals, 2)
+	c.Assert(runs[1].Time, gocheck.Equals, scheduled.Add(time.Hour))
+	c.Assert(runs[1].Token > runs[0].Token, gocheck.Equals, true)
+}
+
+func (s *S) TestExecuteSkipsWhenLocked(c *gocheck.C) {
+	scheduler := &Scheduler{Settings: redisSettings, KeyPrefix: "test:"}
+	other := &Scheduler{Settings: redisSettings, KeyPrefix: "test:"}
+	scheduled := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+	inner := Job{Name: "locked", Run: func(ctx context.Context, run JobRun) error {
+		return nil
+	}}
+	var innerRan bool
+	outer := Job{Name: "locked", Run: func(ctx context.Context, run JobRun) error {
+		innerRan, _ = other.Execute(ctx, inner, scheduled)
+		return nil
+	}}
+	ran, err := scheduler.Execute(context.Background(), outer, scheduled)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(ran, gocheck.Equals, true)
+	c.Assert(innerRan, gocheck.Equals, false)
+}
+
+func (s *S) TestSchedulerRun(c *gocheck.C) {
+	var mu sync.Mutex
+	runs := map[time.Time]int{}
+	schedule, _ := Every(100 * time.Millisecond)
+	job := Job{Name: "tick", Schedule: schedule, Run: func(ctx context.Context, run JobRun) error {
+		mu.Lock()
+		runs[run.Time]++
+		mu.Unlock()
+		return nil
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 450*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		scheduler := &Scheduler{Settings: redisSettings, KeyPrefix: "test:"}
+		scheduler.Add(job)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler.Run(ctx)
+		}()
+	}
+	wg.Wait()
+	c.Assert(len(runs) >= 3, gocheck.Equals, true)
+	for _, count := range runs {
+		c.Assert(count, gocheck.Equals, 1)
+	}
+}
+
+func (s *S) TestParseSchedule(c *gocheck.C) {
+	schedule, err := ParseSchedule("0 0 * * *")
+	c.Assert(err, gocheck.IsNil)
+	from := time.Date(2013, 5, 1, 10, 0, 0, 0, time.UTC)
+	c.Assert(schedule.Next(from), gocheck.Equals, time.Date(2013, 5, 2, 0, 0, 0, 0, time.UTC))
+	_, err = ParseSchedule("every day")
+	c.Assert(err, gocheck.NotNil)
+}
+
+func (s *S) TestEvery(c *gocheck.C) {
+	schedule, err := Every(time.Hour)
+	c.Assert(err, gocheck.IsNil)
+	from := time.Date(2013, 5, 1, 10, 0, 0, 0, time.UTC)
+	c.Assert(schedule.Next(from), gocheck.Equals, from.Add(time.Hour))
+	_, err = Every(0)
+	c.Assert(err, gocheck.ErrorMatches, "leaderboard: schedule interval must be positive, got 0s")
+	_, err = Every(-time.Second)
+	c.Assert(err, gocheck.NotNil)
+}
+
+// stuckSchedule never moves past the time it is given.
+type stuckSchedule struct{}
+
+func (stuckSchedule) Next(after time.Time) time.Time {
+	return after
+}
+
+func (s *S) TestSchedulerStopsStuckSchedule(c *gocheck.C) {
+	logger := &recordingLogger{}
+	runs := 0
+	scheduler := &Scheduler{Settings: redisSettings, KeyPrefix: "test:", Logger: logger}
+	scheduler.Add(Job{Name: "stuck", Schedule: stuckSchedule{}, Run: func(ctx context.Context, run JobRun) error {
+		runs++
+		return nil
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	scheduler.Run(ctx)
+	c.Assert(ctx.Err(), gocheck.IsNil)
+	c.Assert(runs, gocheck.Equals, 0)
+	c.Assert(logger.lines, gocheck.HasLen, 1)
+}
+
+func (s *S) TestJobNamesFollowKeyPrefix(c *gocheck.C) {
+	schedule, _ := Every(time.Hour)
+	east, _ := New(redisSettings, "kills", WithKeyPrefix("east:"))
+	west, _ := New(redisSettings, "kills", WithKeyPrefix("west:"))
+	streaks := NewStreakBoard(redisSettings, "streaks", 10, 24*time.Hour, time.UTC, WithKeyPrefix("east:"))
+	daily := NewDailyBoard(east, time.UTC, 0)
+	history := NewRankHistory(east, 0)
+	replicator, err := NewReplicator(NewReplicatedBoard(west, UpdateMax, "west", 0), NewReplicatedBoard(east, UpdateMax, "east", 0), 0)
+	c.Assert(err, gocheck.IsNil)
+	migrator := NewMigrator(0)
+
+	c.Assert(streaks.SweepJob(schedule).Name, gocheck.Equals, "east:streaks:sweep")
+	c.Assert(daily.CleanupJob(schedule).Name, gocheck.Equals, "east:kills:cleanup")
+	c.Assert(history.SampleJob(schedule, 10).Name, gocheck.Equals, "east:kills:history")
+	c.Assert(replicator.SyncJob(schedule).Name, gocheck.Equals, "east:kills:replicate:west:east")
+	c.Assert(migrator.MigrateJob(schedule, east, west).Name, gocheck.Equals, "migrate:east:kills,west:kills")
+	c.Assert(migrator.MigrateJob(schedule, west).Name, gocheck.Not(gocheck.Equals), migrator.MigrateJob(schedule, east).Name)
+}
@@ -0,0 +1,198 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// topSnapshot is a materialized copy of the first members of a leaderboard,
+// read instead of the leaderboard itself to keep the top page off a hot key.
+type topSnapshot struct {
+	size     int
+	replicas int
+	interval time.Duration
+
+	reads uint32
+
+	mu    sync.Mutex
+	local *topPage
+	taken time.Time
+}
+
+// topPage is the content of a snapshot: the first members and the size of
+// the leaderboard when it was taken.
+type topPage struct {
+	Users []User `json:"users"`
+	Total int    `json:"total"`
+}
+
+/* End Structs model */
+
+/* Private functions */
+
+func (l *Leaderboard) replicaKey(replica int) string {
+	return l.subKey(":top:" + strconv.Itoa(replica))
+}
+
+// takeTop reads the first members and the size of the leaderboard from the
+// leaderboard itself.
+func (l *Leaderboard) takeTop() (*topPage, error) {
+	conn := l.conn()
+	total, err := redis.Int(conn.Do("ZCARD", l.key()))
+	conn.Close()
+	if err != nil {
+		return nil, err
+	}
+	size := l.top.size
+	if size > total {
+		size = total
+	}
+	users := []User{}
+	if size > 0 {
+		users = l.getMembersByRange(size, 0, size-1, false)
+	}
+	return &topPage{Users: users, Total: total}, nil
+}
+
+// readTop returns the snapshot of the top members, or nil when there is no
+// fresh snapshot to read from.
+func (l *Leaderboard) readTop() *topPage {
+	if l.top.replicas == 0 {
+		return l.readLocalTop()
+	}
+	replica := int(atomic.AddUint32(&l.top.reads, 1) % uint32(l.top.replicas))
+	conn := l.conn()
+	defer conn.Close()
+	data, err := redis.Bytes(conn.Do("GET", l.replicaKey(replica)))
+	if err != nil {
+		return nil
+	}
+	page := &topPage{}
+	if err := json.Unmarshal(data, page); err != nil {
+		return nil
+	}
+	return page
+}
+
+// readLocalTop returns the snapshot kept in memory, taking a new one when it
+// is older than the refresh interval.
+func (l *Leaderboard) readLocalTop() *topPage {
+	l.top.mu.Lock()
+	defer l.top.mu.Unlock()
+	now := l.now()
+	if l.top.local != nil && now.Sub(l.top.taken) < l.top.interval {
+		return l.top.local
+	}
+	page, err := l.takeTop()
+	if err != nil {
+		l.logf("error on refresh top snapshot Leaderboard:%s", l.Name)
+		return l.top.local
+	}
+	l.top.local, l.top.taken = page, now
+	return page
+}
+
+// getLeadersFromTop serves a page from the top snapshot. It reports false
+// when the page is not covered by a fresh snapshot.
+func (l *Leaderboard) getLeadersFromTop(page int) ([]User, bool) {
+	if page*l.PageSize > l.top.size {
+		return nil, false
+	}
+	top := l.readTop()
+	if top == nil {
+		return nil, false
+	}
+	pages := (top.Total + l.PageSize - 1) / l.PageSize
+	if page > pages {
+		page = pages
+	}
+	users := make([]User, l.PageSize)
+	if page > 0 {
+		start := (page - 1) * l.PageSize
+		end := start + l.PageSize
+		if end > len(top.Users) {
+			end = len(top.Users)
+		}
+		copy(users, top.Users[start:end])
+	}
+	return users, true
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// WithReplicatedTop serves the pages of GetLeaders covered by the first size
+// members from snapshots copied to several Redis keys, spreading the reads of
+// the top page over the shards holding them. RefreshTop, usually run by
+// RefreshTopJob, copies the snapshots every interval; reads fall back to the
+// leaderboard when a snapshot is missed for three intervals.
+func WithReplicatedTop(size int, replicas int, interval time.Duration) Option {
+	return func(l *Leaderboard) {
+		l.top = &topSnapshot{size: size, replicas: replicas, interval: interval}
+	}
+}
+
+// WithCachedTop serves the pages of GetLeaders covered by the first size
+// members from a snapshot kept in memory, taken again when older than
+// interval.
+func WithCachedTop(size int, interval time.Duration) Option {
+	return func(l *Leaderboard) {
+		l.top = &topSnapshot{size: size, interval: interval}
+	}
+}
+
+// RefreshTop takes a snapshot of the first members and copies it to every
+// replica key, or to memory for a cached top.
+func (l *Leaderboard) RefreshTop() error {
+	if l.top == nil {
+		return nil
+	}
+	page, err := l.takeTop()
+	if err != nil {
+		return err
+	}
+	if l.top.replicas == 0 {
+		l.top.mu.Lock()
+		l.top.local, l.top.taken = page, l.now()
+		l.top.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	conn := l.conn()
+	defer conn.Close()
+	ttl := int64(3 * l.top.interval / time.Millisecond)
+	for replica := 0; replica < l.top.replicas; replica++ {
+		conn.Send("SET", l.replicaKey(replica), data, "PX", ttl)
+	}
+	_, err = conn.Do("")
+	return err
+}
+
+// RefreshTopJob returns a job refreshing the replicated top every interval.
+func (l *Leaderboard) RefreshTopJob() Job {
+	interval := time.Second
+	if l.top != nil {
+		interval = l.top.interval
+	}
+	return Job{
+		Name:     l.key() + ":top",
+		Schedule: intervalSchedule(interval),
+		Run: func(ctx context.Context, run JobRun) error {
+			return l.RefreshTop()
+		},
+	}
+}
+
+/* End Public functions */
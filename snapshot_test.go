@@ -0,0 +1,56 @@
+package leaderboard
+
+import (
+	"strconv"
+	"time"
+
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestWithReplicatedTop(c *gocheck.C) {
+	metrics := &countingMetrics{commands: map[string]int{}}
+	board, err := New(redisSettings, "replicatedTop", WithPageSize(5), WithReplicatedTop(10, 3, time.Second), WithMetrics(metrics))
+	c.Assert(err, gocheck.IsNil)
+	for i := 0; i < 12; i++ {
+		board.RankMember("member_"+strconv.Itoa(i), i)
+	}
+	users := board.GetLeaders(1)
+	c.Assert(users[0].Name, gocheck.Equals, "member_11")
+	c.Assert(metrics.commands["ZREVRANGE"], gocheck.Equals, 1)
+
+	c.Assert(board.RefreshTop(), gocheck.IsNil)
+	board.RankMember("member_12", 12)
+	metrics.commands = map[string]int{}
+	for i := 0; i < 3; i++ {
+		users = board.GetLeaders(2)
+		c.Assert(users[0].Name, gocheck.Equals, "member_6")
+		c.Assert(users[0].Rank, gocheck.Equals, 6)
+	}
+	c.Assert(metrics.commands["GET"], gocheck.Equals, 3)
+	c.Assert(metrics.commands["ZREVRANGE"], gocheck.Equals, 0)
+
+	users = board.GetLeaders(3)
+	c.Assert(users[0].Name, gocheck.Equals, "member_2")
+	c.Assert(metrics.commands["ZREVRANGE"], gocheck.Equals, 1)
+}
+
+func (s *S) TestWithCachedTop(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC))
+	board, _ := New(redisSettings, "cachedTop", WithPageSize(5), WithCachedTop(5, time.Second), WithClock(clock))
+	board.RankMember("dayvson", 10)
+	c.Assert(board.GetLeaders(1)[0].Name, gocheck.Equals, "dayvson")
+	board.RankMember("felipe", 20)
+	c.Assert(board.GetLeaders(1)[0].Name, gocheck.Equals, "dayvson")
+	clock.Advance(time.Second)
+	users := board.GetLeaders(1)
+	c.Assert(users[0].Name, gocheck.Equals, "felipe")
+	c.Assert(users[1].Name, gocheck.Equals, "dayvson")
+	c.Assert(users[2].Rank, gocheck.Equals, 0)
+}
+
+func (s *S) TestTopSnapshotValidation(c *gocheck.C) {
+	_, err := New(redisSettings, "cachedTop", WithPageSize(10), WithCachedTop(5, time.Second))
+	c.Assert(err, gocheck.NotNil)
+	_, err = New(redisSettings, "cachedTop", WithReplicatedTop(100, 3, 0))
+	c.Assert(err, gocheck.NotNil)
+}
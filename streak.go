@@ -0,0 +1,230 @@
+package leaderboard
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// StreakBoard ranks members by consecutive periods of activity. Every member
+// has a current streak and a best-ever streak, each exposed as a Leaderboard.
+// Periods are counted on the wall clock of Location, so a daily streak
+// follows local midnights. A nil Backend uses the pool shared by
+// leaderboards created from RedisSettings.
+type StreakBoard struct {
+	Settings RedisSettings
+	Backend  Backend
+	Name     string
+	PageSize int
+	Period   time.Duration
+	Location *time.Location
+
+	options []Option
+}
+
+type Streak struct {
+	Name    string
+	Current int
+	Best    int
+}
+
+/* End Structs model */
+
+// recordActivityScript moves a member's streak forward. Activity in the
+// period following the last one extends the current streak, a gap restarts
+// it and older periods are ignored.
+var recordActivityScript = redis.NewScript(3, `
+local last = tonumber(redis.call('HGET', KEYS[1], ARGV[1]))
+local period = tonumber(ARGV[2])
+local current = tonumber(redis.call('ZSCORE', KEYS[2], ARGV[1])) or 0
+if not last or period > last then
+	if last and period == last + 1 and current > 0 then
+		current = current + 1
+	else
+		current = 1
+	end
+	redis.call('HSET', KEYS[1], ARGV[1], period)
+	redis.call('ZADD', KEYS[2], current, ARGV[1])
+end
+local best = tonumber(redis.call('ZSCORE', KEYS[3], ARGV[1])) or 0
+if current > best then
+	best = current
+	redis.call('ZADD', KEYS[3], best, ARGV[1])
+end
+return {current, best}
+`)
+
+/* Private functions */
+
+func (s *StreakBoard) conn() redis.Conn {
+	l := s.board("")
+	return l.conn()
+}
+
+// board returns the leaderboard named after the streak board followed by
+// suffix, configured by the options of the streak board.
+func (s *StreakBoard) board(suffix string) Leaderboard {
+	l := NewLeaderboard(s.Settings, s.Name, s.PageSize)
+	for _, option := range s.options {
+		option(&l)
+	}
+	if s.Backend != nil {
+		l.backend = s.Backend
+	}
+	if suffix != "" {
+		return l.derive(suffix)
+	}
+	return l
+}
+
+func (s *StreakBoard) key() string {
+	l := s.board("")
+	return l.key()
+}
+
+func (s *StreakBoard) lastKey() string {
+	l := s.board("")
+	return l.subKey(":last")
+}
+
+// timeOr returns t, or the time of the clock of the board when t is zero.
+func (s *StreakBoard) timeOr(t time.Time) time.Time {
+	if !t.IsZero() {
+		return t
+	}
+	l := s.board("")
+	return l.now()
+}
+
+func (s *StreakBoard) recordActivity(member string, at time.Time) (Streak, error) {
+	conn := s.conn()
+	defer conn.Close()
+	current, best := s.Current(), s.Best()
+	values, err := redis.Ints(recordActivityScript.Do(conn, s.lastKey(), current.key(), best.key(), member, s.periodOf(s.timeOr(at))))
+	if err != nil {
+		return Streak{Name: member}, err
+	}
+	return Streak{Name: member, Current: values[0], Best: values[1]}, nil
+}
+
+// periodOf returns the index of the period holding t, counted from the Unix
+// epoch on the wall clock of the board's location.
+func (s *StreakBoard) periodOf(t time.Time) int64 {
+	local := t.In(s.Location)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+	seconds := int64(s.Period / time.Second)
+	period := wall.Unix() / seconds
+	if wall.Unix()%seconds < 0 {
+		period--
+	}
+	return period
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// NewStreakBoard creates a streak board. A period shorter than a second
+// defaults to a day and a nil location to UTC. Options configure the streak
+// leaderboards, e.g. WithKeyPrefix, and WithClock gives the time used when
+// none is given.
+func NewStreakBoard(settings RedisSettings, name string, pageSize int, period time.Duration, location *time.Location, options ...Option) StreakBoard {
+	if period < time.Second {
+		period = 24 * time.Hour
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return StreakBoard{Settings: settings, Name: name, PageSize: pageSize, Period: period, Location: location, options: options}
+}
+
+// Current is the leaderboard of current streaks. Broken streaks stay on it
+// until BreakStale runs.
+func (s *StreakBoard) Current() Leaderboard {
+	return s.board(":current")
+}
+
+// Best is the leaderboard of best-ever streaks.
+func (s *StreakBoard) Best() Leaderboard {
+	return s.board(":best")
+}
+
+// RecordActivity registers that member was active at the given time, or now
+// by the clock of the board when at is zero.
+func (s *StreakBoard) RecordActivity(member string, at time.Time) (Streak, error) {
+	l := s.board("")
+	result, err := l.invoke("StreakBoard.RecordActivity", []interface{}{member, at}, func(args []interface{}) (interface{}, error) {
+		return s.recordActivity(args[0].(string), args[1].(time.Time))
+	})
+	streak, _ := result.(Streak)
+	return streak, err
+}
+
+// GetStreak returns the streaks of member as of now, the clock of the board
+// telling the time when now is zero. A current streak whose last activity is
+// older than the previous period counts as broken.
+func (s *StreakBoard) GetStreak(member string, now time.Time) (Streak, error) {
+	now = s.timeOr(now)
+	conn := s.conn()
+	defer conn.Close()
+	current, best := s.Current(), s.Best()
+	conn.Send("HGET", s.lastKey(), member)
+	conn.Send("ZSCORE", current.key(), member)
+	conn.Send("ZSCORE", best.key(), member)
+	if err := conn.Flush(); err != nil {
+		return Streak{Name: member}, err
+	}
+	last, lastErr := redis.Int64(conn.Receive())
+	currentStreak, _ := redis.Int(conn.Receive())
+	bestStreak, _ := redis.Int(conn.Receive())
+	if lastErr != nil || last < s.periodOf(now)-1 {
+		currentStreak = 0
+	}
+	return Streak{Name: member, Current: currentStreak, Best: bestStreak}, nil
+}
+
+// BreakStale removes the members whose streak was broken before now, or
+// before the time of the clock of the board when now is zero, from the
+// current streaks leaderboard and returns how many were removed. Run it once
+// per period to keep Current accurate.
+func (s *StreakBoard) BreakStale(now time.Time) (int, error) {
+	now = s.timeOr(now)
+	conn := s.conn()
+	defer conn.Close()
+	current := s.Current()
+	threshold := s.periodOf(now) - 1
+	removed := 0
+	cursor := 0
+	for {
+		values, err := redis.Values(conn.Do("HSCAN", s.lastKey(), cursor, "COUNT", 100))
+		if err != nil {
+			return removed, err
+		}
+		var pairs []string
+		if _, err := redis.Scan(values, &cursor, &pairs); err != nil {
+			return removed, err
+		}
+		args := redis.Args{}.Add(current.key())
+		for i := 0; i+1 < len(pairs); i += 2 {
+			last, err := strconv.ParseInt(pairs[i+1], 10, 64)
+			if err == nil && last < threshold {
+				args = args.Add(pairs[i])
+			}
+		}
+		if len(args) > 1 {
+			n, err := redis.Int(conn.Do("ZREM", args...))
+			if err != nil {
+				return removed, err
+			}
+			removed += n
+		}
+		if cursor == 0 {
+			return removed, nil
+		}
+	}
+}
+
+/* End Public functions */
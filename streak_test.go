@@ -0,0 +1,78 @@
+package leaderboard
+
+import (
+	"time"
+
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestRecordActivity(c *gocheck.C) {
+	daily := NewStreakBoard(redisSettings, "dailyStreak", 10, 24*time.Hour, time.UTC)
+	day := time.Date(2013, 5, 1, 10, 0, 0, 0, time.UTC)
+	daily.RecordActivity("dayvson", day)
+	daily.RecordActivity("dayvson", day.Add(2*time.Hour))
+	streak, err := daily.RecordActivity("dayvson", day.Add(24*time.Hour))
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(streak.Current, gocheck.Equals, 2)
+	c.Assert(streak.Best, gocheck.Equals, 2)
+
+	streak, _ = daily.RecordActivity("dayvson", day.Add(4*24*time.Hour))
+	c.Assert(streak.Current, gocheck.Equals, 1)
+	c.Assert(streak.Best, gocheck.Equals, 2)
+
+	daily.RecordActivity("felipe", day)
+	best := daily.Best()
+	c.Assert(best.GetRank("dayvson"), gocheck.Equals, 1)
+	c.Assert(best.GetRank("felipe"), gocheck.Equals, 2)
+}
+
+func (s *S) TestStreakFollowsLocation(c *gocheck.C) {
+	saoPaulo := time.FixedZone("BRT", -3*60*60)
+	daily := NewStreakBoard(redisSettings, "localStreak", 10, 24*time.Hour, saoPaulo)
+	daily.RecordActivity("arthur", time.Date(2013, 5, 1, 12, 0, 0, 0, time.UTC))
+	streak, _ := daily.RecordActivity("arthur", time.Date(2013, 5, 2, 2, 0, 0, 0, time.UTC))
+	c.Assert(streak.Current, gocheck.Equals, 1)
+	streak, _ = daily.RecordActivity("arthur", time.Date(2013, 5, 2, 4, 0, 0, 0, time.UTC))
+	c.Assert(streak.Current, gocheck.Equals, 2)
+}
+
+func (s *S) TestBreakStale(c *gocheck.C) {
+	daily := NewStreakBoard(redisSettings, "staleStreak", 10, 24*time.Hour, time.UTC)
+	day := time.Date(2013, 5, 1, 10, 0, 0, 0, time.UTC)
+	daily.RecordActivity("dayvson", day)
+	daily.RecordActivity("felipe", day.Add(2*24*time.Hour))
+	now := day.Add(3 * 24 * time.Hour)
+
+	streak, _ := daily.GetStreak("dayvson", now)
+	c.Assert(streak.Current, gocheck.Equals, 0)
+	c.Assert(streak.Best, gocheck.Equals, 1)
+
+	removed, err := daily.BreakStale(now)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(removed, gocheck.Equals, 1)
+	current := daily.Current()
+	c.Assert(current.TotalMembers(), gocheck.Equals, 1)
+	streak, _ = daily.GetStreak("felipe", now)
+	c.Assert(streak.Current, gocheck.Equals, 1)
+}
+
+func (s *S) TestStreakBoardOptions(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 10, 0, 0, 0, time.UTC))
+	daily := NewStreakBoard(redisSettings, "optionsStreak", 10, 24*time.Hour, time.UTC, WithKeyPrefix("game:"), WithClock(clock))
+	daily.RecordActivity("dayvson", time.Time{})
+	clock.Advance(24 * time.Hour)
+	streak, err := daily.RecordActivity("dayvson", time.Time{})
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(streak.Current, gocheck.Equals, 2)
+	c.Assert(daily.lastKey(), gocheck.Equals, "{game:optionsStreak}:last")
+	current := daily.Current()
+	c.Assert(current.key(), gocheck.Equals, "{game:optionsStreak}:current")
+	c.Assert(current.GetRank("dayvson"), gocheck.Equals, 1)
+
+	clock.Advance(2 * 24 * time.Hour)
+	streak, _ = daily.GetStreak("dayvson", time.Time{})
+	c.Assert(streak.Current, gocheck.Equals, 0)
+	removed, err := daily.BreakStale(time.Time{})
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(removed, gocheck.Equals, 1)
+}
@@ -0,0 +1,97 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+/* Structs model */
+
+// Window is a period of time a leaderboard is also ranked over, e.g. to show
+// the scores of the week next to the all time ones. Periods follow the UTC
+// calendar; weeks start on Monday.
+type Window int
+
+const (
+	// WindowDaily ranks members over the current day.
+	WindowDaily Window = iota
+	// WindowWeekly ranks members over the current week.
+	WindowWeekly
+	// WindowMonthly ranks members over the current month.
+	WindowMonthly
+)
+
+/* End Structs model */
+
+var windowNames = map[Window]string{WindowDaily: "daily", WindowWeekly: "weekly", WindowMonthly: "monthly"}
+
+/* Private functions */
+
+// windowStart returns the start of the period of window holding t.
+func windowStart(window Window, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch window {
+	case WindowWeekly:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case WindowMonthly:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// windowEnd returns the end of the period of window starting at start.
+func windowEnd(window Window, start time.Time) time.Time {
+	switch window {
+	case WindowWeekly:
+		return start.AddDate(0, 0, 7)
+	case WindowMonthly:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// sendWindows queues the ranking of a member on the current period of every
+// window of the leaderboard, each period expiring at the end of the next one.
+// It returns how many replies to read back.
+func (l *Leaderboard) sendWindows(conn redis.Conn, member string, score int) int {
+	replies := 0
+	now := l.now()
+	for _, window := range l.windows {
+		start := windowStart(window, now)
+		board := l.Window(window, start)
+		conn.Send("ZADD", board.key(), score, member)
+		replies += 1 + board.sendTrim(conn)
+		expiry := windowEnd(window, windowEnd(window, start)).Sub(now)
+		conn.Send("EXPIRE", board.key(), int64(expiry/time.Second)+1)
+		replies++
+	}
+	return replies
+}
+
+/* End Private functions */
+
+/* Public functions */
+
+// WithWindows also ranks the members given to RankMember and Batch.RankMember
+// on the current period of every window. The leaderboard of a period expires
+// at the end of the next period, so the previous period stays readable.
+func WithWindows(windows ...Window) Option {
+	return func(l *Leaderboard) {
+		l.windows = append(l.windows, windows...)
+	}
+}
+
+// Window returns the leaderboard of the period of window holding at. It
+// shares the options of l but has no windows of its own.
+func (l *Leaderboard) Window(window Window, at time.Time) Leaderboard {
+	start := windowStart(window, at)
+	layout := dateLayout
+	if window == WindowMonthly {
+		layout = "2006-01"
+	}
+	return l.derive(":" + windowNames[window] + ":" + start.Format(layout))
+}
+
+/* End Public functions */
@@ -0,0 +1,73 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"launchpad.net/gocheck"
+)
+
+func (s *S) TestWindowStart(c *gocheck.C) {
+	at := time.Date(2013, 5, 1, 18, 30, 0, 0, time.FixedZone("BRT", -3*60*60))
+	c.Assert(windowStart(WindowDaily, at), gocheck.Equals, time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(windowStart(WindowWeekly, at), gocheck.Equals, time.Date(2013, 4, 29, 0, 0, 0, 0, time.UTC))
+	c.Assert(windowStart(WindowMonthly, at), gocheck.Equals, time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC))
+	sunday := time.Date(2013, 5, 5, 23, 0, 0, 0, time.UTC)
+	c.Assert(windowStart(WindowWeekly, sunday), gocheck.Equals, time.Date(2013, 4, 29, 0, 0, 0, 0, time.UTC))
+}
+
+func (s *S) TestWithWindows(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 12, 0, 0, 0, time.UTC))
+	kills, err := New(redisSettings, "windowed", WithWindows(WindowDaily, WindowWeekly, WindowMonthly), WithClock(clock))
+	c.Assert(err, gocheck.IsNil)
+	kills.RankMember("dayvson", 10)
+	clock.Advance(24 * time.Hour)
+	batch := NewBatch()
+	felipe := batch.RankMember(&kills, "felipe", 20)
+	c.Assert(batch.Exec(), gocheck.IsNil)
+	user, err := felipe.Result()
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Rank, gocheck.Equals, 1)
+
+	firstDay := kills.Window(WindowDaily, time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(firstDay.key(), gocheck.Equals, "{windowed}:daily:2013-05-01")
+	c.Assert(firstDay.TotalMembers(), gocheck.Equals, 1)
+	today := kills.Window(WindowDaily, clock.Now())
+	c.Assert(today.GetLeaders(1)[0].Name, gocheck.Equals, "felipe")
+	c.Assert(today.TotalMembers(), gocheck.Equals, 1)
+	week := kills.Window(WindowWeekly, clock.Now())
+	c.Assert(week.key(), gocheck.Equals, "{windowed}:weekly:2013-04-29")
+	c.Assert(week.TotalMembers(), gocheck.Equals, 2)
+	month := kills.Window(WindowMonthly, clock.Now())
+	c.Assert(month.key(), gocheck.Equals, "{windowed}:monthly:2013-05")
+	c.Assert(month.TotalMembers(), gocheck.Equals, 2)
+	c.Assert(kills.TotalMembers(), gocheck.Equals, 2)
+
+	month.RankMember("arthur", 30)
+	c.Assert(kills.TotalMembers(), gocheck.Equals, 2)
+	_, err = New(redisSettings, "windowed", WithWindows(Window(7)))
+	c.Assert(err, gocheck.ErrorMatches, "leaderboard: unknown window 7")
+}
+
+func (s *S) TestWindowsExpire(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 12, 0, 0, 0, time.UTC))
+	kills, _ := New(redisSettings, "windowedExpiry", WithWindows(WindowDaily), WithClock(clock))
+	kills.RankMember("dayvson", 10)
+	conn := kills.conn()
+	defer conn.Close()
+	ttl, _ := redis.Int(conn.Do("TTL", "{windowedExpiry}:daily:2013-05-01"))
+	c.Assert(ttl, gocheck.Equals, 36*60*60+1)
+}
+
+func (s *S) TestWindowsReportErrors(c *gocheck.C) {
+	clock := NewManualClock(time.Date(2013, 5, 1, 12, 0, 0, 0, time.UTC))
+	logger := &recordingLogger{}
+	kills, _ := New(redisSettings, "windowedErrors", WithWindows(WindowDaily), WithClock(clock), WithLogger(logger))
+	conn := kills.conn()
+	defer conn.Close()
+	conn.Do("SET", "{windowedErrors}:daily:2013-05-01", "not a sorted set")
+	user, err := kills.RankMember("dayvson", 10)
+	c.Assert(err, gocheck.IsNil)
+	c.Assert(user.Rank, gocheck.Equals, 1)
+	c.Assert(len(logger.lines), gocheck.Equals, 1)
+}